@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+const anthropicAPIVersion = "2023-06-01"
+
+// AnthropicChat repairs transcriptions and describes images with the
+// Anthropic Messages API
+type AnthropicChat struct {
+	APIKey  string
+	BaseURL string
+	Model   string
+	client  *http.Client
+}
+
+type anthropicContent struct {
+	Type   string                `json:"type"`
+	Text   string                `json:"text,omitempty"`
+	Source *anthropicImageSource `json:"source,omitempty"`
+}
+
+type anthropicImageSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type"`
+	Data      string `json:"data"`
+}
+
+type anthropicMessage struct {
+	Role    string             `json:"role"`
+	Content []anthropicContent `json:"content"`
+}
+
+type anthropicRequest struct {
+	Model     string             `json:"model"`
+	MaxTokens int                `json:"max_tokens"`
+	System    string             `json:"system,omitempty"`
+	Messages  []anthropicMessage `json:"messages"`
+}
+
+type anthropicResponse struct {
+	Content []anthropicContent `json:"content"`
+}
+
+func NewAnthropicChat(model string) (*AnthropicChat, error) {
+	apiKey := os.Getenv("ANTHROPIC_API_KEY")
+	if apiKey == "" {
+		apiKey = config.AnthropicKey
+		if apiKey == "" {
+			return nil, fmt.Errorf("Anthropic API key is not set")
+		}
+	}
+
+	return &AnthropicChat{
+		APIKey:  apiKey,
+		BaseURL: strings.TrimSuffix(config.AnthropicBaseURL, "/"),
+		Model:   model,
+		client:  &http.Client{},
+	}, nil
+}
+
+func (c *AnthropicChat) Name() string {
+	return "anthropic"
+}
+
+func (c *AnthropicChat) createMessage(ctx context.Context, req anthropicRequest) (string, error) {
+	var resp anthropicResponse
+	err := postJSON(ctx, c.client, c.BaseURL+"/v1/messages", map[string]string{
+		"x-api-key":         c.APIKey,
+		"anthropic-version": anthropicAPIVersion,
+	}, req, &resp)
+
+	if err != nil {
+		return "", err
+	}
+
+	var text strings.Builder
+	for _, content := range resp.Content {
+		if content.Type == "text" {
+			text.WriteString(content.Text)
+		}
+	}
+
+	if text.Len() == 0 {
+		return "", fmt.Errorf("No text content returned")
+	}
+
+	return text.String(), nil
+}
+
+func (c *AnthropicChat) Repair(ctx context.Context, transcribedText string, instructions string) (string, error) {
+	// messages must alternate roles, so the instructions and transcription are
+	// sent as two blocks of a single user message
+	text, err := c.createMessage(ctx, anthropicRequest{
+		Model:     c.Model,
+		MaxTokens: 1024,
+		System:    fixPrompt,
+		Messages: []anthropicMessage{
+			{
+				Role: "user",
+				Content: []anthropicContent{
+					{Type: "text", Text: instructions},
+					{Type: "text", Text: fmt.Sprintf("Transcription: %s", transcribedText)},
+				},
+			},
+		},
+	})
+
+	if err != nil {
+		return "", fmt.Errorf("Error sending transcription fix request: %w", err)
+	}
+
+	return text, nil
+}
+
+func (c *AnthropicChat) DescribeImage(ctx context.Context, imagePath string) (string, error) {
+	imageData, err := os.ReadFile(imagePath)
+	if err != nil {
+		return "", fmt.Errorf("Error reading image file: %v", err)
+	}
+
+	text, err := c.createMessage(ctx, anthropicRequest{
+		Model:     c.Model,
+		MaxTokens: 1024,
+		System:    describeImagePrompt,
+		Messages: []anthropicMessage{
+			{
+				Role: "user",
+				Content: []anthropicContent{
+					{
+						Type: "image",
+						Source: &anthropicImageSource{
+							Type:      "base64",
+							MediaType: "image/png",
+							Data:      base64.StdEncoding.EncodeToString(imageData),
+						},
+					},
+				},
+			},
+		},
+	})
+
+	if err != nil {
+		return "", fmt.Errorf("Error sending image description request: %w", err)
+	}
+
+	return text, nil
+}
@@ -1,145 +1,341 @@
 package main
 
 import (
-	"bytes"
 	"context"
+	"errors"
 	"fmt"
-	"io"
-	"io/ioutil"
 	"log"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	portaudio "github.com/gordonklaus/portaudio"
-	"github.com/viert/go-lame"
 )
 
-const sampleRate = 44100
 const bufferSize = 256
-const maxRecordSeconds = 30
 const minRecordSeconds = 1
 const debug = false
 
-func recordAudio(ctx context.Context, stopCh <-chan struct{}) ([]int16, error) {
-	ctx, cancel := context.WithTimeout(ctx, maxRecordSeconds*time.Second)
-	defer cancel()
+// why a recording was ended
+type StopReason string
 
-	err := portaudio.Initialize()
-	if err != nil {
-		return nil, fmt.Errorf("Error initializing PortAudio: %v", err)
+const (
+	StopReasonManual      StopReason = "manual"
+	StopReasonSilence     StopReason = "silence"
+	StopReasonMaxDuration StopReason = "max-duration"
+)
+
+// returned by recordAudio when the recording is shorter than minRecordSeconds,
+// usually an accidental tap of the hotkey, so it's not reported as an error
+var errRecordingTooShort = errors.New("Aborting, recording too short")
+
+type Recording struct {
+	Samples    []int16
+	SampleRate int
+	StopReason StopReason
+}
+
+// record audio from the input device until stopCh is closed, the voice
+// activity detector hears silence after speech (if enabled), or the maximum
+// recording length is reached. Cancelling the context discards the recording.
+// If capture is not nil the recording is taken from the always on capture,
+// starting with its pre-roll, otherwise a new stream is opened.
+// If segmentCh is not nil the audio is also cut at pauses in speech while
+// recording, and each segment is sent to segmentCh. segmentCh is closed when
+// recording ends
+func recordAudio(ctx context.Context, capture *AudioCapture, stopCh <-chan struct{}, segmentCh chan<- []int16) (*Recording, error) {
+	sampleRate := recordingSampleRate(capture)
+
+	if segmentCh != nil {
+		// registered first so it runs after the stream has been stopped
+		defer close(segmentCh)
 	}
-	defer portaudio.Terminate()
 
-	// TODO: allow user to specify input device, the default is failing on my computer
-	inputDevice, err := findDeviceByName("pipewire")
-	if err != nil {
-		return nil, fmt.Errorf("Error finding input device: %v", err)
+	// the level is only available while recording
+	meter := newLevelMeter(sampleRate, taskManager.SetLevel)
+	defer taskManager.ClearLevel()
+
+	var maxDurationCh <-chan time.Time
+	if config.MaxRecordSeconds > 0 {
+		timer := time.NewTimer(time.Duration(config.MaxRecordSeconds) * time.Second)
+		defer timer.Stop()
+		maxDurationCh = timer.C
+	}
+
+	var vad *VAD
+	silenceCh := make(chan struct{})
+	var silenceOnce sync.Once
+	silenceSpan := time.Duration(config.VADSilenceMs) * time.Millisecond
+
+	if config.VADSilenceMs > 0 {
+		vad = NewVAD(sampleRate, config.VADSensitivity)
+	}
+
+	// the segmenter detects pauses to cut the audio at for streaming
+	var segmenter *VAD
+	segmentStart := 0
+	pauseSpan := time.Duration(config.StreamingPauseMs) * time.Millisecond
+
+	if segmentCh != nil {
+		segmenter = NewVAD(sampleRate, config.VADSensitivity)
 	}
 
 	var recordingBuffer []int16
-	stream, err := portaudio.OpenStream(portaudio.StreamParameters{
-		Input: portaudio.StreamDeviceParameters{
-			Device:   inputDevice,
-			Channels: 1,
-			Latency:  inputDevice.DefaultLowInputLatency,
-		},
-		SampleRate:      sampleRate,
-		FramesPerBuffer: bufferSize,
-	}, func(in []int16) {
+	process := func(in []int16) {
 		if debug {
 			log.Printf("Chunk length: %d\n", len(in))
 			log.Printf("Input chunk: %+v\n", in)
 		}
 
 		recordingBuffer = append(recordingBuffer, in...)
-	})
+		meter.Process(in)
 
-	if err != nil {
-		return nil, fmt.Errorf("Error opening default stream: %v", err)
+		if vad != nil {
+			vad.Process(in)
+			if vad.HeardSpeech() && vad.TrailingSilence() >= silenceSpan {
+				silenceOnce.Do(func() { close(silenceCh) })
+			}
+		}
+
+		if segmenter != nil {
+			segmenter.Process(in)
+			if segmenter.HeardSpeech() && segmenter.TrailingSilence() >= pauseSpan {
+				end := len(recordingBuffer)
+				// can't block the audio callback, if the reader is behind then
+				// this audio will be included in the next segment
+				select {
+				case segmentCh <- recordingBuffer[segmentStart:end:end]:
+					segmentStart = end
+					segmenter.Reset()
+				default:
+				}
+			}
+		}
 	}
-	defer stream.Close()
+
+	// stops process from being called, after which recordingBuffer is safe
+	// to read
+	var stopInput func()
 
 	startTime := time.Now()
 
-	if err := stream.Start(); err != nil {
-		return nil, fmt.Errorf("Error starting stream: %v", err)
+	if capture != nil {
+		capture.Attach(process)
+		defer capture.Detach()
+		stopInput = capture.Detach
+	} else {
+		err := portaudio.Initialize()
+		if err != nil {
+			return nil, fmt.Errorf("Error initializing PortAudio: %v", err)
+		}
+		defer portaudio.Terminate()
+
+		inputDevice, err := findInputDevice()
+		if err != nil {
+			return nil, fmt.Errorf("Error finding input device: %v", err)
+		}
+
+		stream, err := portaudio.OpenStream(portaudio.StreamParameters{
+			Input: portaudio.StreamDeviceParameters{
+				Device:   inputDevice,
+				Channels: 1,
+				Latency:  inputDevice.DefaultLowInputLatency,
+			},
+			SampleRate:      float64(sampleRate),
+			FramesPerBuffer: bufferSize,
+		}, process)
+
+		if err != nil {
+			return nil, fmt.Errorf("Error opening default stream: %v", err)
+		}
+		defer stream.Close()
+
+		if err := stream.Start(); err != nil {
+			return nil, fmt.Errorf("Error starting stream: %v", err)
+		}
+		defer stream.Stop()
+
+		stopInput = func() { stream.Stop() }
 	}
-	defer stream.Stop()
 
 	log.Println("Recording, waiting for stop signal...")
+	stopReason := StopReasonManual
 	select {
 	case <-stopCh:
-		stream.Stop()
+		stopInput()
 		log.Println("Recording finished.")
+	case <-silenceCh:
+		stopInput()
+		stopReason = StopReasonSilence
+		log.Println("Recording finished, silence detected.")
+	case <-maxDurationCh:
+		stopInput()
+		stopReason = StopReasonMaxDuration
+		log.Printf("Recording finished, reached maximum length of %d seconds.\n", config.MaxRecordSeconds)
 	case <-ctx.Done():
-		stream.Stop()
+		stopInput()
 		return nil, fmt.Errorf("Recording cancelled")
 	}
 
 	if time.Since(startTime) < minRecordSeconds*time.Second {
-		return nil, fmt.Errorf("Aborting, recording too short: < %d seconds", minRecordSeconds)
+		return nil, errRecordingTooShort
+	}
+
+	// send whatever is left after the last pause, unless it's only silence
+	if segmenter != nil && segmenter.HeardSpeech() && segmentStart < len(recordingBuffer) {
+		segmentCh <- recordingBuffer[segmentStart:]
 	}
 
-	return recordingBuffer, nil
+	return &Recording{
+		Samples:    recordingBuffer,
+		SampleRate: sampleRate,
+		StopReason: stopReason,
+	}, nil
 }
 
-// encode and write an audio recording to a MP3 file to a temporary file path
-// and return the path
-func writeRecordingToMP3(recordingBuffer []int16) (string, error) {
-	// Convert int16 buffer to byte buffer
-	byteBuffer := new(bytes.Buffer)
-	for _, sample := range recordingBuffer {
-		byteBuffer.WriteByte(byte(sample & 0xff))
-		byteBuffer.WriteByte(byte((sample >> 8) & 0xff))
+// remove the leading and trailing audio that is quieter than threshold (RMS
+// from 0 to 1), leaving padding around the loud section. Recordings that are
+// entirely quiet are returned unchanged
+func trimSilence(samples []int16, sampleRate int, threshold float64, padding time.Duration) []int16 {
+	frameSize := sampleRate / 100 // 10ms frames
+	if frameSize == 0 || len(samples) < frameSize {
+		return samples
 	}
 
-	// Write to temporary file
-	tempFile, err := ioutil.TempFile("", fmt.Sprintf("talkxtyper-%d-*.mp3", time.Now().Unix()))
-	if err != nil {
-		return "", fmt.Errorf("Error creating temporary file: %v", err)
+	start, end := -1, -1
+	for offset := 0; offset < len(samples); offset += frameSize {
+		frameEnd := offset + frameSize
+		if frameEnd > len(samples) {
+			frameEnd = len(samples)
+		}
+
+		rms, _ := frameStats(samples[offset:frameEnd])
+		if rms > threshold {
+			if start < 0 {
+				start = offset
+			}
+			end = frameEnd
+		}
+	}
+
+	if start < 0 {
+		return samples
 	}
-	defer tempFile.Close()
 
-	// Initialize LAME encoder with the output file handle
-	encoder := lame.NewEncoder(tempFile)
-	encoder.SetNumChannels(1)
-	encoder.SetInSamplerate(sampleRate)
-	defer encoder.Close()
+	paddingSamples := int(padding.Seconds() * float64(sampleRate))
+	start = max(0, start-paddingSamples)
+	end = min(len(samples), end+paddingSamples)
 
-	// Encode to MP3
-	if _, err := io.Copy(encoder, byteBuffer); err != nil {
-		return "", fmt.Errorf("Error encoding MP3: %v", err)
+	return samples[start:end]
+}
+
+// the rate recordAudio records at, the always on capture keeps the rate it was
+// started with if the config has changed since
+func recordingSampleRate(capture *AudioCapture) int {
+	if capture != nil {
+		return capture.SampleRate
+	}
+	return config.CaptureSampleRate
+}
+
+// the sample rate audio recorded at recordRate is converted to before
+// encoding, 0 in the config means keep the recording rate
+func uploadSampleRate(recordRate int) int {
+	if config.UploadSampleRate > 0 {
+		return config.UploadSampleRate
 	}
+	return recordRate
+}
 
-	return tempFile.Name(), nil
+// duration of a buffer of samples
+func samplesDuration(samples []int16, sampleRate int) time.Duration {
+	return time.Duration(len(samples)) * time.Second / time.Duration(sampleRate)
 }
 
-func playRecording(recordingBuffer []int16) error {
-	outputDevice, err := findDeviceByName("pipewire")
+func playRecording(recordingBuffer []int16, sampleRate int) error {
+	outputDevice, err := findOutputDevice()
 	if err != nil {
 		return fmt.Errorf("Error finding output device: %v", err)
 	}
 
-	if err := playRecordingToDevice(recordingBuffer, outputDevice); err != nil {
+	if err := playRecordingToDevice(recordingBuffer, sampleRate, outputDevice); err != nil {
 		return fmt.Errorf("Error during playback: %v", err)
 	}
 
 	return nil
 }
 
-func findDeviceByName(name string) (*portaudio.DeviceInfo, error) {
+// find the device to record from, using the InputDevice config option if set,
+// otherwise the PortAudio default input device
+func findInputDevice() (*portaudio.DeviceInfo, error) {
+	if config.InputDevice == "" {
+		return portaudio.DefaultInputDevice()
+	}
+
+	device, err := findDevice(config.InputDevice, func(d *portaudio.DeviceInfo) bool {
+		return d.MaxInputChannels > 0
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Input device '%s': %v", config.InputDevice, err)
+	}
+	return device, nil
+}
+
+// find the device to play back to, using the OutputDevice config option if
+// set, otherwise the PortAudio default output device
+func findOutputDevice() (*portaudio.DeviceInfo, error) {
+	if config.OutputDevice == "" {
+		return portaudio.DefaultOutputDevice()
+	}
+
+	device, err := findDevice(config.OutputDevice, func(d *portaudio.DeviceInfo) bool {
+		return d.MaxOutputChannels > 0
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Output device '%s': %v", config.OutputDevice, err)
+	}
+	return device, nil
+}
+
+// find a device by exact name, device index, or case insensitive substring of
+// the name (in that order). usable filters out devices that don't have the
+// required channels
+func findDevice(spec string, usable func(*portaudio.DeviceInfo) bool) (*portaudio.DeviceInfo, error) {
 	devices, err := portaudio.Devices()
 	if err != nil {
 		return nil, fmt.Errorf("Error listing devices: %v", err)
 	}
+
 	for _, device := range devices {
-		if device.Name == name {
+		if device.Name == spec && usable(device) {
 			return device, nil
 		}
 	}
-	return nil, fmt.Errorf("Output device '%s' not found", name)
+
+	if index, err := strconv.Atoi(spec); err == nil {
+		if index < 0 || index >= len(devices) {
+			return nil, fmt.Errorf("device index %d out of range (%d devices)", index, len(devices))
+		}
+		if !usable(devices[index]) {
+			return nil, fmt.Errorf("device %d (%s) has no usable channels", index, devices[index].Name)
+		}
+		return devices[index], nil
+	}
+
+	lowerSpec := strings.ToLower(spec)
+	for _, device := range devices {
+		if strings.Contains(strings.ToLower(device.Name), lowerSpec) && usable(device) {
+			return device, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no matching device found")
 }
 
-// init portaudio and print out all deivices with their names and number of inputs and outpuits
+// init portaudio and print out all devices with their index, host API, number
+// of inputs and outputs, and default sample rate. The devices that will be used
+// for recording and playback are marked
 func debugAudioDevices() {
 	err := portaudio.Initialize()
 	if err != nil {
@@ -152,20 +348,48 @@ func debugAudioDevices() {
 		log.Fatalf("Error listing devices: %v", err)
 	}
 
-	for _, device := range devices {
-		fmt.Printf("Name: %s, MaxInputChannels: %d, MaxOutputChannels: %d\n", device.Name, device.MaxInputChannels, device.MaxOutputChannels)
+	inputDevice, err := findInputDevice()
+	if err != nil {
+		log.Printf("Warning: %v", err)
 	}
+
+	outputDevice, err := findOutputDevice()
+	if err != nil {
+		log.Printf("Warning: %v", err)
+	}
+
+	for index, device := range devices {
+		marker := "  "
+		switch {
+		case device == inputDevice && device == outputDevice:
+			marker = "IO"
+		case device == inputDevice:
+			marker = "I "
+		case device == outputDevice:
+			marker = " O"
+		}
+
+		hostApi := ""
+		if device.HostApi != nil {
+			hostApi = device.HostApi.Name
+		}
+
+		fmt.Printf("%s %2d: %s [%s], MaxInputChannels: %d, MaxOutputChannels: %d, DefaultSampleRate: %.0f\n",
+			marker, index, device.Name, hostApi, device.MaxInputChannels, device.MaxOutputChannels, device.DefaultSampleRate)
+	}
+
+	fmt.Println("\nI = input device, O = output device (set with InputDevice and OutputDevice in config)")
 }
 
-func playRecordingToDevice(recordingBuffer []int16, outputDevice *portaudio.DeviceInfo) error {
-	// play back the recording using portaudio, 44100 Hz, 16 bit signed mono audio
+func playRecordingToDevice(recordingBuffer []int16, sampleRate int, outputDevice *portaudio.DeviceInfo) error {
+	// play back the recording using portaudio, 16 bit signed mono audio
 	playbackStream, err := portaudio.OpenStream(portaudio.StreamParameters{
 		Output: portaudio.StreamDeviceParameters{
 			Device:   outputDevice,
 			Channels: 1,
 			Latency:  outputDevice.DefaultLowOutputLatency,
 		},
-		SampleRate:      sampleRate,
+		SampleRate:      float64(sampleRate),
 		FramesPerBuffer: bufferSize,
 	}, func(out []int16) {
 		for i := range out {
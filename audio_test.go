@@ -0,0 +1,42 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestTrimSilence(t *testing.T) {
+	const sampleRate = 16000
+	const padding = 300 * time.Millisecond
+
+	quiet := func(duration time.Duration) []int16 {
+		return testSignal(sampleRate, duration, 0, 0, 0.002)
+	}
+	speech := func(duration time.Duration) []int16 {
+		return testSignal(sampleRate, duration, 0.3, 300, 0.002)
+	}
+
+	tests := []struct {
+		name     string
+		samples  []int16
+		duration time.Duration
+	}{
+		// nothing above the threshold is left alone rather than emptied
+		{"silence", quiet(2 * time.Second), 2 * time.Second},
+		{"too short", speech(5 * time.Millisecond), 5 * time.Millisecond},
+		{"speech then silence", slices.Concat(speech(time.Second), quiet(2*time.Second)), time.Second + padding},
+		{"silence around speech", slices.Concat(quiet(time.Second), speech(500*time.Millisecond), quiet(2*time.Second)), 500*time.Millisecond + 2*padding},
+		{"clipped", slices.Concat(quiet(time.Second), testSignal(sampleRate, 500*time.Millisecond, 4, 300, 0)), 500*time.Millisecond + padding},
+	}
+
+	for _, test := range tests {
+		trimmed := trimSilence(test.samples, sampleRate, 0.01, padding)
+
+		// speech is found to the nearest 10ms frame
+		duration := samplesDuration(trimmed, sampleRate)
+		if duration < test.duration-10*time.Millisecond || duration > test.duration+10*time.Millisecond {
+			t.Errorf("%s: trimmed to %v, want %v", test.name, duration, test.duration)
+		}
+	}
+}
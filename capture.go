@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	portaudio "github.com/gordonklaus/portaudio"
+)
+
+// AudioCapture keeps the input stream open between recordings. The most recent
+// audio is held in a ring buffer so a recording can start with the moments
+// before it was requested, avoiding a clipped first word while the stream
+// opens
+type AudioCapture struct {
+	SampleRate int
+
+	stream *portaudio.Stream
+
+	mu         sync.Mutex
+	ring       []int16
+	ringPos    int
+	ringFull   bool
+	subscriber func([]int16)
+}
+
+func NewAudioCapture(sampleRate int, preRoll time.Duration) *AudioCapture {
+	size := int(preRoll.Seconds() * float64(sampleRate))
+	return &AudioCapture{
+		SampleRate: sampleRate,
+		ring:       make([]int16, max(size, 1)),
+	}
+}
+
+// open the input device and start filling the ring buffer
+func (c *AudioCapture) Start() error {
+	if err := portaudio.Initialize(); err != nil {
+		return fmt.Errorf("Error initializing PortAudio: %v", err)
+	}
+
+	inputDevice, err := findInputDevice()
+	if err != nil {
+		portaudio.Terminate()
+		return fmt.Errorf("Error finding input device: %v", err)
+	}
+
+	stream, err := portaudio.OpenStream(portaudio.StreamParameters{
+		Input: portaudio.StreamDeviceParameters{
+			Device:   inputDevice,
+			Channels: 1,
+			Latency:  inputDevice.DefaultLowInputLatency,
+		},
+		SampleRate:      float64(c.SampleRate),
+		FramesPerBuffer: bufferSize,
+	}, c.process)
+
+	if err != nil {
+		portaudio.Terminate()
+		return fmt.Errorf("Error opening capture stream: %v", err)
+	}
+
+	if err := stream.Start(); err != nil {
+		stream.Close()
+		portaudio.Terminate()
+		return fmt.Errorf("Error starting capture stream: %v", err)
+	}
+
+	c.stream = stream
+	return nil
+}
+
+// close the input device. A recording that is attached stops receiving audio
+func (c *AudioCapture) Stop() error {
+	if c.stream == nil {
+		return nil
+	}
+
+	defer portaudio.Terminate()
+
+	stopErr := c.stream.Stop()
+	closeErr := c.stream.Close()
+	c.stream = nil
+
+	if stopErr != nil {
+		return fmt.Errorf("Error stopping capture stream: %v", stopErr)
+	}
+	if closeErr != nil {
+		return fmt.Errorf("Error closing capture stream: %v", closeErr)
+	}
+
+	return nil
+}
+
+// called from the audio thread with each buffer of input
+func (c *AudioCapture) process(in []int16) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.subscriber != nil {
+		c.subscriber(in)
+		return
+	}
+
+	for len(in) > 0 {
+		n := copy(c.ring[c.ringPos:], in)
+		in = in[n:]
+		c.ringPos += n
+		if c.ringPos == len(c.ring) {
+			c.ringPos = 0
+			c.ringFull = true
+		}
+	}
+}
+
+// send all audio from now on to fn, starting with the contents of the ring
+// buffer. fn is called from the audio thread so it must not block
+func (c *AudioCapture) Attach(fn func([]int16)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var preRoll []int16
+	if c.ringFull {
+		preRoll = append(preRoll, c.ring[c.ringPos:]...)
+	}
+	preRoll = append(preRoll, c.ring[:c.ringPos]...)
+
+	c.ringPos = 0
+	c.ringFull = false
+
+	if len(preRoll) > 0 {
+		fn(preRoll)
+	}
+
+	c.subscriber = fn
+}
+
+// stop sending audio to the attached function, once this returns it won't be
+// called again. Safe to call multiple times
+func (c *AudioCapture) Detach() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.subscriber = nil
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// how many chunks are sent for transcription at the same time
+const maxParallelChunks = 4
+
+// a section of a long recording, Offset is where it starts in the full
+// recording and Overlap is how much of its start is shared with the previous
+// chunk
+type audioChunk struct {
+	Samples []int16
+	Offset  time.Duration
+	Overlap time.Duration
+}
+
+// split a recording into chunks of at most chunkLength. Each cut is made at the
+// quietest point in the last quarter of the chunk so that words aren't split,
+// and the next chunk starts overlap before the cut
+func splitIntoChunks(samples []int16, sampleRate int, chunkLength time.Duration, overlap time.Duration) []audioChunk {
+	chunkSamples := int(chunkLength.Seconds() * float64(sampleRate))
+	overlapSamples := int(overlap.Seconds() * float64(sampleRate))
+	frameSize := sampleRate / 10 // search in 100ms frames
+
+	if chunkSamples <= overlapSamples || chunkSamples < frameSize {
+		return []audioChunk{{Samples: samples}}
+	}
+
+	var chunks []audioChunk
+	start := 0
+	startOverlap := 0
+
+	for {
+		if len(samples)-start <= chunkSamples {
+			chunks = append(chunks, audioChunk{
+				Samples: samples[start:],
+				Offset:  samplesDuration(samples[:start], sampleRate),
+				Overlap: samplesDuration(samples[:startOverlap], sampleRate),
+			})
+			break
+		}
+
+		// find the quietest frame in the search window
+		searchStart := start + chunkSamples*3/4
+		searchEnd := start + chunkSamples
+		cut := searchEnd
+		quietest := -1.0
+		for offset := searchStart; offset+frameSize <= searchEnd; offset += frameSize {
+			rms, _ := frameStats(samples[offset : offset+frameSize])
+			if quietest < 0 || rms < quietest {
+				quietest = rms
+				cut = offset + frameSize/2
+			}
+		}
+
+		chunks = append(chunks, audioChunk{
+			Samples: samples[start:cut],
+			Offset:  samplesDuration(samples[:start], sampleRate),
+			Overlap: samplesDuration(samples[:startOverlap], sampleRate),
+		})
+
+		// always move forward, even when the overlap reaches back past the
+		// start of this chunk
+		start = max(start+1, cut-overlapSamples)
+		startOverlap = cut - start
+	}
+
+	return chunks
+}
+
+// transcribe a long recording by splitting it into chunks, transcribing them
+// in parallel, and stitching the text back together
+func transcribeChunked(ctx context.Context, samples []int16, sampleRate int, instructions string) (*TranscriptionResult, error) {
+	transcriber, err := getTranscriber()
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := splitIntoChunks(samples, sampleRate,
+		time.Duration(config.ChunkSeconds)*time.Second,
+		time.Duration(config.ChunkOverlapSeconds)*time.Second)
+
+	log.Printf("Transcribing recording in %d chunks\n", len(chunks))
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]*TranscriptionResult, len(chunks))
+	errs := make([]error, len(chunks))
+	semaphore := make(chan struct{}, maxParallelChunks)
+
+	var wg sync.WaitGroup
+	for i, chunk := range chunks {
+		wg.Add(1)
+		go func(i int, chunk audioChunk) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			chunkPath, err := writeRecordingToFile(chunk.Samples, sampleRate)
+			if err != nil {
+				errs[i] = fmt.Errorf("Error writing audio file for chunk %d: %v", i, err)
+				cancel()
+				return
+			}
+			defer os.Remove(chunkPath)
+
+			results[i], errs[i] = transcribeWithRetry(ctx, transcriber, chunkPath, defaultTranscriptionOptions())
+			if errs[i] != nil {
+				errs[i] = fmt.Errorf("Error transcribing chunk %d: %v", i, errs[i])
+				cancel()
+			}
+		}(i, chunk)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, &StageError{Stage: StageTranscribe, Err: err}
+		}
+	}
+
+	result := NewTranscriptionResult()
+	result.Backend = transcriber.Name()
+
+	for i, chunkResult := range results {
+		result.Original = stitchText(result.Original, chunkResult.Original)
+
+		offset := chunks[i].Offset.Seconds()
+		overlap := chunks[i].Overlap.Seconds()
+		for _, segment := range chunkResult.Segments {
+			// segments in the overlap were already covered by the previous chunk
+			if segment.End <= overlap {
+				continue
+			}
+			segment.Start += offset
+			segment.End += offset
+			result.Segments = append(result.Segments, segment)
+		}
+	}
+
+	return repairTranscription(ctx, result, instructions)
+}
+
+// lowercase a word and remove punctuation so that the same word transcribed
+// in two chunks compares as equal
+func normalizeWord(word string) string {
+	return strings.ToLower(strings.TrimFunc(word, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}))
+}
+
+// append next to previous, removing the words at the start of next that
+// repeat the end of previous. The first few words of next may be a partially
+// heard word from the cut so matching can start slightly into next
+func stitchText(previous string, next string) string {
+	previous = strings.TrimSpace(previous)
+	next = strings.TrimSpace(next)
+
+	if previous == "" {
+		return next
+	}
+	if next == "" {
+		return previous
+	}
+
+	prevWords := strings.Fields(previous)
+	nextWords := strings.Fields(next)
+
+	const maxOverlapWords = 30
+	const maxSkipWords = 3
+
+	bestLength, bestSkip := 0, 0
+
+	for skip := 0; skip < maxSkipWords && skip < len(nextWords); skip++ {
+		for length := min(maxOverlapWords, len(prevWords), len(nextWords)-skip); length > bestLength; length-- {
+			matched := true
+			for i := 0; i < length; i++ {
+				if normalizeWord(prevWords[len(prevWords)-length+i]) != normalizeWord(nextWords[skip+i]) {
+					matched = false
+					break
+				}
+			}
+
+			// a single short word is too likely to match by accident
+			if matched && (length > 1 || len(normalizeWord(nextWords[skip])) > 3) {
+				bestLength, bestSkip = length, skip
+				break
+			}
+		}
+	}
+
+	if bestLength == 0 {
+		return previous + " " + next
+	}
+
+	remaining := nextWords[bestSkip+bestLength:]
+	if len(remaining) == 0 {
+		return previous
+	}
+
+	return previous + " " + strings.Join(remaining, " ")
+}
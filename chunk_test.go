@@ -0,0 +1,97 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestSplitIntoChunksAdvances(t *testing.T) {
+	// 1kHz keeps the samples as whole milliseconds
+	const sampleRate = 1000
+	chunkLength := 5 * time.Second
+
+	noisy := make([]int16, 60*sampleRate)
+	random := rand.New(rand.NewSource(1))
+	for i := range noisy {
+		noisy[i] = int16(random.Intn(20000) - 10000)
+	}
+
+	inputs := map[string][]int16{
+		"silent": make([]int16, 60*sampleRate),
+		"noisy":  noisy,
+	}
+
+	overlaps := []time.Duration{
+		0,
+		3 * time.Second,
+		// the longest overlap that validation allows, and past it
+		3749 * time.Millisecond,
+		3750 * time.Millisecond,
+		4 * time.Second,
+		chunkLength - time.Millisecond,
+	}
+
+	for name, samples := range inputs {
+		for _, overlap := range overlaps {
+			done := make(chan []audioChunk, 1)
+			go func() {
+				done <- splitIntoChunks(samples, sampleRate, chunkLength, overlap)
+			}()
+
+			var chunks []audioChunk
+			select {
+			case chunks = <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatalf("%s with %v overlap: splitting didn't finish", name, overlap)
+			}
+
+			if len(chunks) > len(samples) {
+				t.Fatalf("%s with %v overlap: %d chunks", name, overlap, len(chunks))
+			}
+
+			// chunks start in order, and each one begins its overlap before
+			// where the previous one ended
+			end := time.Duration(0)
+			for i, chunk := range chunks {
+				if i > 0 && chunk.Offset <= chunks[i-1].Offset {
+					t.Fatalf("%s with %v overlap: chunk %d starts at %v, before %v", name, overlap, i, chunk.Offset, chunks[i-1].Offset)
+				}
+				if chunk.Offset+chunk.Overlap != end {
+					t.Fatalf("%s with %v overlap: chunk %d overlaps to %v, previous ended at %v", name, overlap, i, chunk.Offset+chunk.Overlap, end)
+				}
+				if length := samplesDuration(chunk.Samples, sampleRate); length > chunkLength {
+					t.Fatalf("%s with %v overlap: chunk %d is %v long", name, overlap, i, length)
+				}
+				end = chunk.Offset + samplesDuration(chunk.Samples, sampleRate)
+			}
+
+			if end != samplesDuration(samples, sampleRate) {
+				t.Errorf("%s with %v overlap: chunks end at %v", name, overlap, end)
+			}
+		}
+	}
+}
+
+func TestConfigLimitsChunkOverlap(t *testing.T) {
+	tests := []struct {
+		chunk, overlap, expected int
+	}{
+		{120, 2, 2},
+		{5, 3, 3},
+		{5, 4, 3},
+		{5, 5, 3},
+		{1, 1, 0},
+		// chunking disabled
+		{0, 10, 10},
+	}
+
+	for _, test := range tests {
+		c := Config{ChunkSeconds: test.chunk, ChunkOverlapSeconds: test.overlap}
+		c.validate()
+		if c.ChunkOverlapSeconds != test.expected {
+			t.Errorf("ChunkSeconds %d, ChunkOverlapSeconds %d: got %d, want %d",
+				test.chunk, test.overlap, c.ChunkOverlapSeconds, test.expected)
+		}
+	}
+}
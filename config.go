@@ -6,21 +6,216 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
+	"runtime"
 )
 
 type Config struct {
-	OpenAIKey     string
+	OpenAIKey string
+
+	// OpenAI compatible API settings, change the base URL to use another
+	// provider that implements the OpenAI API. Set OpenAIAPIType to "azure" for
+	// Azure OpenAI deployments
+	OpenAIBaseURL      string
+	OpenAIAPIType      string
+	TranscriptionModel string
+	RepairModel        string
+	VisionModel        string
+
+	// language of the spoken audio as an ISO-639-1 code, "auto" to let the
+	// transcription backend detect it
+	Language    string
+	Temperature float32
+
+	// which provider repairs transcriptions and describes the screen:
+	// "openai" (default), "anthropic" or "ollama"
+	RepairProvider string
+	VisionProvider string
+
+	AnthropicKey     string
+	AnthropicBaseURL string
+	AnthropicModel   string
+	// empty to describe the screen with AnthropicModel
+	AnthropicVisionModel string
+
+	OllamaURL         string
+	OllamaModel       string
+	OllamaVisionModel string
+
 	IncludeScreen bool
 	IncludeNvim   bool
 	ListenAddress string
+	// other origins that can use the history API from a browser, eg. the
+	// extension's chrome-extension://<id>
+	AllowedOrigins []string
+
+	// include context pushed to the /context HTTP endpoint, it expires after
+	// ContextTTLSeconds unless the request sets its own ttl. 0 never expires
+	IncludePushedContext bool
+	ContextTTLSeconds    int
+
+	// how long each context provider ("screen", "nvim", "http") has to return
+	// before it's left out of the repair prompt
+	ContextTimeoutMs map[string]int
+
+	// extra nvim server addresses, unix socket paths or host:port, for nvim
+	// instances that can't be found from the focused window, like over ssh
+	NvimSockets []string
+
+	// where transcriptions are sent: "type" (default), "paste", "nvim",
+	// "stdout", "file" or "command"
+	Output string
+	// delay between characters for the "type" output
+	TypeDelayMs int
+	// key combination for the "paste" output, defaults to ctrl+v (cmd+v on
+	// macOS)
+	PasteKeys string
+	// file the "file" output appends to
+	OutputFile string
+	// shell command the "command" output runs with the text on stdin
+	OutputCommand string
+
+	// settings used while a matching application has focus, the first match
+	// is used
+	AppProfiles []AppProfile
+
+	// global hotkeys, from action name to a key combination like
+	// "ctrl+shift+space". An empty combination disables the action
+	Hotkeys map[string]string
+
+	// output to use for each hotkey action, overriding Output and AppProfiles
+	HotkeyOutputs map[string]string
+
+	// how the toggle hotkey works: "toggle" (default) starts recording on one
+	// press and stops on the next, "push-to-talk" records while it's held
+	RecordHotkeyMode string
+
+	// audio device to record from or play back to, matched by exact name,
+	// device index, or substring of the name. Empty uses the PortAudio default.
+	// See -audio-devices for a list
+	InputDevice  string
+	OutputDevice string
+
+	// recording stops automatically after this many seconds, 0 for no limit
+	MaxRecordSeconds int
+
+	// recordings longer than ChunkSeconds are split at quiet points into
+	// chunks that overlap by ChunkOverlapSeconds and transcribed in parallel
+	ChunkSeconds        int
+	ChunkOverlapSeconds int
+
+	// stop recording automatically after this much silence following speech,
+	// 0 disables. Sensitivity is from 0 to 1, higher detects quieter speech
+	VADSilenceMs   int
+	VADSensitivity float64
+
+	// in streaming mode the recording is cut at pauses of StreamingPauseMs and
+	// each part is transcribed and typed while still recording
+	StreamingMode    bool
+	StreamingPauseMs int
+
+	// the rate audio is recorded at, and the rate it's converted to before
+	// upload. Whisper models work with 16kHz audio internally. Set
+	// UploadSampleRate to 0 to upload at the capture rate
+	CaptureSampleRate int
+	UploadSampleRate  int
+
+	// keep the microphone open between recordings and start each recording
+	// with the last PreRollMs of audio, so the first word isn't cut off while
+	// the input stream opens
+	PreRoll   bool
+	PreRollMs int
+
+	// file format recordings are uploaded and stored in: "mp3" (default),
+	// "wav" or "flac"
+	AudioFormat string
+
+	// leading and trailing audio quieter than the threshold (RMS from 0 to 1)
+	// is removed before upload, keeping the padding around speech. 0 disables
+	TrimThreshold float64
+	TrimPaddingMs int
+
+	// which service to send audio to for transcription: "openai" (default) or
+	// "http" for a self-hosted whisper server at WhisperURL
+	TranscriptionBackend string
+	WhisperURL           string
+	WhisperModel         string
+	WhisperKey           string
+
+	// transient API errors, like timeouts, rate limits and server errors, are
+	// retried up to RetryAttempts times. The wait starts at RetryDelayMs and
+	// doubles after each attempt
+	RetryAttempts int
+	RetryDelayMs  int
+
+	// where transcriptions and their audio are saved, defaults to
+	// talkxtyper/history in the XDG data directory
+	HistoryDir string
+	// the oldest history is removed past any of these limits, 0 for no limit
+	HistoryMaxEntries int
+	HistoryMaxAgeDays int
+	// total size of the saved audio
+	HistoryMaxMB int
 }
 
 var config = Config{
+	TranscriptionModel: "whisper-1",
+	RepairModel:        "gpt-4o",
+	VisionModel:        "gpt-4o",
+	Language:           "en",
+	Temperature:        0.5,
+
+	Hotkeys: map[string]string{
+		"toggle":        "alt+b",
+		"abort":         "alt+c",
+		"one-shot-stop": "escape",
+	},
+	RecordHotkeyMode: "toggle",
+
+	Output:      "type",
+	TypeDelayMs: 2,
+
+	ContextTTLSeconds: 600,
+
+	MaxRecordSeconds:    600,
+	ChunkSeconds:        120,
+	ChunkOverlapSeconds: 2,
+
+	CaptureSampleRate: 44100,
+	UploadSampleRate:  16000,
+	PreRollMs:         500,
+	AudioFormat:       "mp3",
+	VADSensitivity:    0.5,
+	StreamingPauseMs:  700,
+	TrimThreshold:     0.01,
+	TrimPaddingMs:     300,
+
+	RetryAttempts: 2,
+	RetryDelayMs:  1000,
+
+	AnthropicBaseURL: "https://api.anthropic.com",
+	AnthropicModel:   "claude-3-5-sonnet-20240620",
+
+	OllamaURL:         "http://localhost:11434",
+	OllamaModel:       "llama3",
+	OllamaVisionModel: "llava",
+
+	HistoryMaxEntries: 1000,
+	HistoryMaxMB:      500,
+
 	// ListenAddress: "localhost:9898",
 	// IncludeScreen: true,
 	// IncludeNvim: true,
 }
 
+// the language to pass to the transcription backend, empty for auto detect
+func (c *Config) TranscriptionLanguage() string {
+	if c.Language == "auto" {
+		return ""
+	}
+	return c.Language
+}
+
 func getConfigPath() (string, error) {
 	configDir, err := os.UserConfigDir()
 	if err != nil {
@@ -29,6 +224,53 @@ func getConfigPath() (string, error) {
 	return fmt.Sprintf("%s/talkxtyper-config.json", configDir), nil
 }
 
+// the directory for application data, $XDG_DATA_HOME or the platform's
+// equivalent
+func getDataDir() (string, error) {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return dir, nil
+	}
+
+	if runtime.GOOS == "windows" {
+		if dir := os.Getenv("LocalAppData"); dir != "" {
+			return dir, nil
+		}
+		return "", fmt.Errorf("LocalAppData is not set")
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("Error finding home directory: %v", err)
+	}
+
+	if runtime.GOOS == "darwin" {
+		return filepath.Join(home, "Library", "Application Support"), nil
+	}
+	return filepath.Join(home, ".local", "share"), nil
+}
+
+func getHistoryDir() (string, error) {
+	if config.HistoryDir != "" {
+		return config.HistoryDir, nil
+	}
+
+	dataDir, err := getDataDir()
+	if err != nil {
+		return "", fmt.Errorf("Error getting data directory: %v", err)
+	}
+	return filepath.Join(dataDir, "talkxtyper", "history"), nil
+}
+
+// fix settings that can't work together, logging what was changed
+func (c *Config) validate() {
+	// chunks are cut in their last quarter, so a longer overlap would start
+	// the next chunk before the previous one
+	if maxOverlap := (c.ChunkSeconds*3 - 1) / 4; c.ChunkSeconds > 0 && c.ChunkOverlapSeconds > maxOverlap {
+		log.Printf("ChunkOverlapSeconds must be less than 3/4 of ChunkSeconds, using %d instead of %d\n", max(0, maxOverlap), c.ChunkOverlapSeconds)
+		c.ChunkOverlapSeconds = max(0, maxOverlap)
+	}
+}
+
 func readConfig() error {
 	configPath, err := getConfigPath()
 	if err != nil {
@@ -49,6 +291,8 @@ func readConfig() error {
 		return fmt.Errorf("Error unmarshalling config file: %v", err)
 	}
 
+	config.validate()
+
 	log.Printf("Configuration loaded: %s\n", configPath)
 
 	return nil
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ContextProvider gathers information about what the user is doing, which is
+// given to the repair prompt to help correct the transcription
+type ContextProvider interface {
+	// identifies the provider in config and logs
+	Name() string
+	// the section header in the repair prompt
+	Title() string
+	Gather(ctx context.Context) (string, error)
+}
+
+// a provider and the config setting that enables it, each one has a checkbox
+// in the tray
+type contextProviderOption struct {
+	Provider ContextProvider
+	Label    string
+	Tooltip  string
+	Enabled  *bool
+}
+
+var contextProviders = []contextProviderOption{
+	{
+		Provider: ScreenContext{},
+		Label:    "Include screen",
+		Tooltip:  "Analyze the screen to augment the transcription",
+		Enabled:  &config.IncludeScreen,
+	},
+	{
+		Provider: NvimContext{},
+		Label:    "Include nvim",
+		Tooltip:  "Include text from current nvim viewport in the transcription",
+		Enabled:  &config.IncludeNvim,
+	},
+	{
+		Provider: PushedContext{},
+		Label:    "Include pushed context",
+		Tooltip:  "Include context sent to the /context HTTP endpoint by other tools",
+		Enabled:  &config.IncludePushedContext,
+	},
+}
+
+// how long each provider has before it's skipped, overridden by
+// ContextTimeoutMs in the config
+var defaultContextTimeouts = map[string]time.Duration{
+	"screen": 30 * time.Second,
+	"nvim":   2 * time.Second,
+	"http":   2 * time.Second,
+}
+
+func contextTimeout(name string) time.Duration {
+	if ms := config.ContextTimeoutMs[name]; ms > 0 {
+		return time.Duration(ms) * time.Millisecond
+	}
+	if timeout, ok := defaultContextTimeouts[name]; ok {
+		return timeout
+	}
+	return 5 * time.Second
+}
+
+// the providers to use for a task
+func enabledContextProviders(options TaskOptions) []ContextProvider {
+	if options.Raw {
+		return nil
+	}
+
+	var providers []ContextProvider
+	for _, option := range contextProviders {
+		if *option.Enabled || (options.ForceScreen && option.Provider.Name() == "screen") {
+			providers = append(providers, option.Provider)
+		}
+	}
+	return providers
+}
+
+// run the providers concurrently and merge what they return into repair
+// instructions with a section for each one, along with the names of the
+// providers that were included. Providers that fail or run out of time are
+// left out. Returns an empty string if there's no context
+func gatherContext(ctx context.Context, providers []ContextProvider) (string, []string) {
+	results := make([]string, len(providers))
+
+	var wg sync.WaitGroup
+	for i, provider := range providers {
+		wg.Add(1)
+		go func(i int, provider ContextProvider) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(ctx, contextTimeout(provider.Name()))
+			defer cancel()
+
+			type gathered struct {
+				text string
+				err  error
+			}
+
+			// not every provider can be interrupted, so don't wait on it
+			// past the timeout
+			done := make(chan gathered, 1)
+			go func() {
+				text, err := provider.Gather(ctx)
+				done <- gathered{text, err}
+			}()
+
+			var result gathered
+			select {
+			case result = <-done:
+			case <-ctx.Done():
+				result.err = ctx.Err()
+			}
+
+			if result.err != nil {
+				log.Printf("Error gathering %s context: %v\n", provider.Name(), result.err)
+				return
+			}
+
+			log.Printf("Context from %s: %s\n", provider.Name(), result.text)
+			results[i] = strings.TrimSpace(result.text)
+		}(i, provider)
+	}
+	wg.Wait()
+
+	var sections []string
+	var sources []string
+	for i, provider := range providers {
+		if results[i] != "" {
+			sections = append(sections, fmt.Sprintf("## %s\n\n%s", provider.Title(), results[i]))
+			sources = append(sources, provider.Name())
+		}
+	}
+
+	if len(sections) == 0 {
+		return "", nil
+	}
+
+	return "The following sections describe what the user is doing while speaking. Use them to correct names, technical terms and other words that may have been misheard.\n\n" +
+		strings.Join(sections, "\n\n"), sources
+}
+
+// ScreenContext describes a screenshot with the vision model
+type ScreenContext struct{}
+
+func (p ScreenContext) Name() string {
+	return "screen"
+}
+
+func (p ScreenContext) Title() string {
+	return "Screen"
+}
+
+func (p ScreenContext) Gather(ctx context.Context) (string, error) {
+	return describeScreen(ctx)
+}
+
+// NvimContext reads the text around the cursor, or the visible text, from the
+// nvim in the focused window
+type NvimContext struct{}
+
+func (p NvimContext) Name() string {
+	return "nvim"
+}
+
+func (p NvimContext) Title() string {
+	return "Text editor (nvim)"
+}
+
+func (p NvimContext) Gather(ctx context.Context) (string, error) {
+	nvimClient := NewNvimClient()
+	if err := nvimClient.FindActiveNvim(); err != nil {
+		return "", err
+	}
+
+	log.Printf("Using nvim socket: %s", nvimClient.socketFile)
+
+	currentMode, err := nvimClient.GetCurrentMode()
+	if err != nil {
+		return "", fmt.Errorf("Error getting current nvim mode: %v", err)
+	}
+
+	switch currentMode {
+	case InsertMode:
+		insertionText, err := nvimClient.GetInsertionText("{{CURSOR}}")
+		if err != nil {
+			return "", fmt.Errorf("Error getting insertion text: %v", err)
+		}
+
+		return fmt.Sprintf(
+			"The user is inserting into a text editor with the following content. The cursor is located at {{CURSOR}}:\n%s",
+			insertionText,
+		), nil
+
+	case NormalMode, VisualMode, CommandMode:
+		visibleText, err := nvimClient.GetVisibleText()
+		if err != nil {
+			return "", fmt.Errorf("Error getting visible text: %v", err)
+		}
+
+		return fmt.Sprintf(
+			"The user is in a text editor with the following content:\n%s",
+			visibleText,
+		), nil
+
+	default:
+		log.Printf("Unhandled nvim mode, skipping description: %s", currentMode)
+		return "", nil
+	}
+}
+
+// StoredContext is text pushed to the /context HTTP endpoint by other tools,
+// like a browser extension or editor script, eg. vocabulary for what the user
+// is working on
+type StoredContext struct {
+	Text string
+	// where the context came from, context pushed from the same source
+	// replaces it
+	Source string
+	// the context is only used while a matching application has focus
+	Scope     AppMatch
+	UpdatedAt time.Time
+	// zero for context that doesn't expire
+	ExpiresAt time.Time
+}
+
+func (c *StoredContext) Expired(now time.Time) bool {
+	return !c.ExpiresAt.IsZero() && now.After(c.ExpiresAt)
+}
+
+// PushedContext provides the stored context that applies to the focused
+// application
+type PushedContext struct{}
+
+func (p PushedContext) Name() string {
+	return "http"
+}
+
+func (p PushedContext) Title() string {
+	return "Context from the user's tools"
+}
+
+func (p PushedContext) Gather(ctx context.Context) (string, error) {
+	entries := taskManager.GetContext()
+
+	// only looked up if some context is scoped
+	var app *ActiveApplication
+
+	var parts []string
+	for _, entry := range entries {
+		if entry.Scope != (AppMatch{}) {
+			if app == nil {
+				activeApp := getActiveApplication()
+				app = &activeApp
+			}
+
+			if !entry.Scope.Matches(*app) {
+				continue
+			}
+		}
+
+		if entry.Source != "" {
+			parts = append(parts, fmt.Sprintf("From %s:\n%s", entry.Source, entry.Text))
+		} else {
+			parts = append(parts, entry.Text)
+		}
+	}
+
+	return strings.Join(parts, "\n\n"), nil
+}
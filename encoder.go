@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/viert/go-lame"
+)
+
+// AudioEncoder writes 16 bit mono samples in an audio file format
+type AudioEncoder interface {
+	// name of the format, also used as the file extension
+	Format() string
+	ContentType() string
+	Encode(w io.Writer, samples []int16, sampleRate int) error
+}
+
+func getAudioEncoder(format string) (AudioEncoder, error) {
+	switch format {
+	case "", "mp3":
+		return MP3Encoder{}, nil
+	case "wav":
+		return WAVEncoder{}, nil
+	case "flac":
+		return FLACEncoder{}, nil
+	default:
+		return nil, fmt.Errorf("Unknown audio format: %s", format)
+	}
+}
+
+// the content type for audio stored in the given format
+func audioContentType(format string) string {
+	encoder, err := getAudioEncoder(format)
+	if err != nil {
+		return "application/octet-stream"
+	}
+	return encoder.ContentType()
+}
+
+// encode and write an audio recording in the configured format to a temporary
+// file and return the path
+func writeRecordingToFile(recordingBuffer []int16, sampleRate int) (string, error) {
+	encoder, err := getAudioEncoder(config.AudioFormat)
+	if err != nil {
+		return "", err
+	}
+
+	tempFile, err := ioutil.TempFile("", fmt.Sprintf("talkxtyper-%d-*.%s", time.Now().Unix(), encoder.Format()))
+	if err != nil {
+		return "", fmt.Errorf("Error creating temporary file: %v", err)
+	}
+	defer tempFile.Close()
+
+	if err := encoder.Encode(tempFile, recordingBuffer, sampleRate); err != nil {
+		os.Remove(tempFile.Name())
+		return "", fmt.Errorf("Error encoding %s: %v", encoder.Format(), err)
+	}
+
+	return tempFile.Name(), nil
+}
+
+// MP3Encoder uses LAME
+type MP3Encoder struct{}
+
+func (MP3Encoder) Format() string {
+	return "mp3"
+}
+
+func (MP3Encoder) ContentType() string {
+	return "audio/mpeg"
+}
+
+func (MP3Encoder) Encode(w io.Writer, samples []int16, sampleRate int) error {
+	// Convert int16 buffer to byte buffer
+	byteBuffer := new(bytes.Buffer)
+	for _, sample := range samples {
+		byteBuffer.WriteByte(byte(sample & 0xff))
+		byteBuffer.WriteByte(byte((sample >> 8) & 0xff))
+	}
+
+	// Initialize LAME encoder with the output file handle
+	encoder := lame.NewEncoder(w)
+	encoder.SetNumChannels(1)
+	encoder.SetInSamplerate(sampleRate)
+	defer encoder.Close()
+
+	// Encode to MP3
+	if _, err := io.Copy(encoder, byteBuffer); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// WAVEncoder writes uncompressed 16 bit PCM
+type WAVEncoder struct{}
+
+func (WAVEncoder) Format() string {
+	return "wav"
+}
+
+func (WAVEncoder) ContentType() string {
+	return "audio/wav"
+}
+
+func (WAVEncoder) Encode(w io.Writer, samples []int16, sampleRate int) error {
+	const channels = 1
+	const bitsPerSample = 16
+
+	dataSize := uint32(len(samples) * 2)
+	blockAlign := uint16(channels * bitsPerSample / 8)
+
+	out := bufio.NewWriter(w)
+
+	header := []interface{}{
+		[4]byte{'R', 'I', 'F', 'F'},
+		uint32(36 + dataSize),
+		[4]byte{'W', 'A', 'V', 'E'},
+		[4]byte{'f', 'm', 't', ' '},
+		uint32(16), // fmt chunk size
+		uint16(1),  // PCM
+		uint16(channels),
+		uint32(sampleRate),
+		uint32(sampleRate) * uint32(blockAlign), // byte rate
+		blockAlign,
+		uint16(bitsPerSample),
+		[4]byte{'d', 'a', 't', 'a'},
+		dataSize,
+	}
+
+	for _, field := range header {
+		if err := binary.Write(out, binary.LittleEndian, field); err != nil {
+			return err
+		}
+	}
+
+	if err := binary.Write(out, binary.LittleEndian, samples); err != nil {
+		return err
+	}
+
+	return out.Flush()
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestWAVEncoder(t *testing.T) {
+	samples := []int16{0, 1, -1, 32767, -32768, 12345}
+
+	var out bytes.Buffer
+	if err := (WAVEncoder{}).Encode(&out, samples, 22050); err != nil {
+		t.Fatal(err)
+	}
+
+	data := out.Bytes()
+	if len(data) != 44+len(samples)*2 {
+		t.Fatalf("got %d bytes, want a 44 byte header and %d bytes of samples", len(data), len(samples)*2)
+	}
+
+	u16 := func(offset int) uint16 { return binary.LittleEndian.Uint16(data[offset:]) }
+	u32 := func(offset int) uint32 { return binary.LittleEndian.Uint32(data[offset:]) }
+
+	for offset, tag := range map[int]string{0: "RIFF", 8: "WAVE", 12: "fmt ", 36: "data"} {
+		if got := string(data[offset : offset+4]); got != tag {
+			t.Errorf("offset %d: %q, want %q", offset, got, tag)
+		}
+	}
+
+	fields := []struct {
+		name     string
+		value    uint32
+		expected uint32
+	}{
+		{"RIFF size", u32(4), uint32(len(data) - 8)},
+		{"fmt size", u32(16), 16},
+		{"format", uint32(u16(20)), 1},
+		{"channels", uint32(u16(22)), 1},
+		{"sample rate", u32(24), 22050},
+		{"byte rate", u32(28), 22050 * 2},
+		{"block align", uint32(u16(32)), 2},
+		{"bits per sample", uint32(u16(34)), 16},
+		{"data size", u32(40), uint32(len(samples) * 2)},
+	}
+	for _, field := range fields {
+		if field.value != field.expected {
+			t.Errorf("%s = %d, want %d", field.name, field.value, field.expected)
+		}
+	}
+
+	for i, sample := range samples {
+		if got := int16(u16(44 + i*2)); got != sample {
+			t.Errorf("sample %d = %d, want %d", i, got, sample)
+		}
+	}
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HistoryExportFormat is a format the history can be exported in
+type HistoryExportFormat struct {
+	Name        string
+	Extension   string
+	ContentType string
+}
+
+var historyExportFormats = []HistoryExportFormat{
+	{"jsonl", "jsonl", "application/jsonl"},
+	{"csv", "csv", "text/csv"},
+	{"markdown", "md", "text/markdown"},
+	// subtitles are for a single entry with segment timestamps
+	{"srt", "srt", "application/x-subrip"},
+	{"vtt", "vtt", "text/vtt"},
+	// all of the above with the recordings
+	{"zip", "zip", "application/zip"},
+}
+
+func getHistoryExportFormat(name string) (HistoryExportFormat, error) {
+	for _, format := range historyExportFormats {
+		if format.Name == name || format.Extension == name {
+			return format, nil
+		}
+	}
+
+	var names []string
+	for _, format := range historyExportFormats {
+		names = append(names, format.Name)
+	}
+	return HistoryExportFormat{}, fmt.Errorf("Unknown export format: %s (possible values: %s)", name, strings.Join(names, ", "))
+}
+
+// write entries, oldest first, in an export format
+func exportHistory(w io.Writer, format HistoryExportFormat, history *HistoryStore, entries []*TranscriptionResult) error {
+	switch format.Name {
+	case "jsonl":
+		return exportHistoryJSONL(w, entries, history.AudioFile)
+	case "csv":
+		return exportHistoryCSV(w, entries, history.AudioFile)
+	case "markdown":
+		return exportHistoryMarkdown(w, entries)
+	case "srt", "vtt":
+		if len(entries) != 1 {
+			return fmt.Errorf("Subtitles are exported for a single entry, choose one by UUID")
+		}
+		if len(entries[0].Segments) == 0 {
+			return fmt.Errorf("Entry %s has no segment timestamps", entries[0].UUID)
+		}
+		return exportSubtitles(w, format.Name, entries[0].Segments)
+	case "zip":
+		return exportHistoryZip(w, history, entries)
+	default:
+		return fmt.Errorf("Unknown export format: %s", format.Name)
+	}
+}
+
+// the name of an entry's recording inside the export
+func exportAudioName(entry *TranscriptionResult) string {
+	if entry.AudioFormat == "" {
+		return ""
+	}
+	return path.Join("audio", entry.UUID+"."+entry.AudioFormat)
+}
+
+// each line is an entry with AudioPath, where its recording is
+func exportHistoryJSONL(w io.Writer, entries []*TranscriptionResult, audioPath func(*TranscriptionResult) string) error {
+	encoder := json.NewEncoder(w)
+	for _, entry := range entries {
+		line := struct {
+			*TranscriptionResult
+			AudioPath string `json:",omitempty"`
+		}{entry, audioPath(entry)}
+
+		if err := encoder.Encode(line); err != nil {
+			return fmt.Errorf("Error writing JSONL: %v", err)
+		}
+	}
+	return nil
+}
+
+func exportHistoryCSV(w io.Writer, entries []*TranscriptionResult, audioPath func(*TranscriptionResult) string) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{
+		"uuid", "created_at", "parent_uuid", "backend", "duration", "trimmed_duration",
+		"original", "modified", "repair_prompt", "context_sources", "output", "warning",
+		"error", "failed_stage", "audio_path",
+	})
+
+	for _, entry := range entries {
+		writer.Write([]string{
+			entry.UUID,
+			entry.CreatedAt.Format(time.RFC3339),
+			entry.ParentUUID,
+			entry.Backend,
+			strconv.FormatFloat(entry.Duration, 'f', 2, 64),
+			strconv.FormatFloat(entry.TrimmedDuration, 'f', 2, 64),
+			entry.Original,
+			entry.Modified,
+			entry.RepairPrompt,
+			strings.Join(entry.ContextSources, ","),
+			entry.Output,
+			entry.Warning,
+			entry.Error,
+			entry.FailedStage,
+			audioPath(entry),
+		})
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("Error writing CSV: %v", err)
+	}
+	return nil
+}
+
+// a journal with a heading for each day
+func exportHistoryMarkdown(w io.Writer, entries []*TranscriptionResult) error {
+	var out strings.Builder
+	out.WriteString("# Dictation history\n")
+
+	var day string
+	for _, entry := range entries {
+		created := entry.CreatedAt.Local()
+
+		if entryDay := created.Format("2006-01-02"); entryDay != day {
+			day = entryDay
+			fmt.Fprintf(&out, "\n## %s\n", day)
+		}
+
+		fmt.Fprintf(&out, "\n### %s\n\n", created.Format("15:04:05"))
+
+		details := []string{fmt.Sprintf("%.1fs", entry.Duration)}
+		if entry.Backend != "" {
+			details = append(details, entry.Backend)
+		}
+		if len(entry.ContextSources) > 0 {
+			details = append(details, "context: "+strings.Join(entry.ContextSources, ", "))
+		}
+		if entry.ParentUUID != "" {
+			details = append(details, "revision of "+entry.ParentUUID)
+		}
+		fmt.Fprintf(&out, "_%s_\n\n", strings.Join(details, ", "))
+
+		if entry.Error != "" {
+			fmt.Fprintf(&out, "**Failed to %s:** %s\n\n", entry.FailedStage, entry.Error)
+		}
+
+		out.WriteString(entry.String() + "\n")
+
+		if entry.Modified != "" && entry.Modified != entry.Original {
+			fmt.Fprintf(&out, "\n> Transcribed as: %s\n", strings.ReplaceAll(entry.Original, "\n", "\n> "))
+		}
+	}
+
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// SRT or WebVTT subtitles from the segments
+func exportSubtitles(w io.Writer, format string, segments []TranscriptionSegment) error {
+	timestamp := func(seconds float64) string {
+		ms := int64(seconds*1000 + 0.5)
+		separator := ","
+		if format == "vtt" {
+			separator = "."
+		}
+		return fmt.Sprintf("%02d:%02d:%02d%s%03d", ms/3600000, ms/60000%60, ms/1000%60, separator, ms%1000)
+	}
+
+	var out strings.Builder
+	if format == "vtt" {
+		out.WriteString("WEBVTT\n\n")
+	}
+
+	for i, segment := range segments {
+		if format == "srt" {
+			fmt.Fprintf(&out, "%d\n", i+1)
+		}
+		fmt.Fprintf(&out, "%s --> %s\n%s\n\n", timestamp(segment.Start), timestamp(segment.End), strings.TrimSpace(segment.Text))
+	}
+
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// a bundle with the history in each format, the recordings, and subtitles for
+// the entries with segments
+func exportHistoryZip(w io.Writer, history *HistoryStore, entries []*TranscriptionResult) error {
+	bundle := zip.NewWriter(w)
+
+	// only link recordings that made it into the bundle
+	included := make(map[string]bool)
+	audioPath := func(entry *TranscriptionResult) string {
+		if included[entry.UUID] {
+			return exportAudioName(entry)
+		}
+		return ""
+	}
+
+	for _, entry := range entries {
+		audio, err := history.ReadAudio(entry)
+		if err != nil {
+			continue
+		}
+
+		// recordings are stored as they are, mp3 and flac don't compress any
+		// further
+		file, err := bundle.CreateHeader(&zip.FileHeader{
+			Name:     exportAudioName(entry),
+			Method:   zip.Store,
+			Modified: entry.CreatedAt,
+		})
+		if err != nil {
+			return fmt.Errorf("Error writing zip: %v", err)
+		}
+		if _, err := file.Write(audio); err != nil {
+			return fmt.Errorf("Error writing zip: %v", err)
+		}
+		included[entry.UUID] = true
+	}
+
+	files := []struct {
+		name  string
+		write func(io.Writer) error
+	}{
+		{"history.jsonl", func(w io.Writer) error { return exportHistoryJSONL(w, entries, audioPath) }},
+		{"history.csv", func(w io.Writer) error { return exportHistoryCSV(w, entries, audioPath) }},
+		{"history.md", func(w io.Writer) error { return exportHistoryMarkdown(w, entries) }},
+	}
+
+	for _, entry := range entries {
+		if len(entry.Segments) == 0 {
+			continue
+		}
+
+		for _, format := range []string{"srt", "vtt"} {
+			files = append(files, struct {
+				name  string
+				write func(io.Writer) error
+			}{
+				path.Join("subtitles", entry.UUID+"."+format),
+				func(w io.Writer) error { return exportSubtitles(w, format, entry.Segments) },
+			})
+		}
+	}
+
+	for _, f := range files {
+		file, err := bundle.Create(f.name)
+		if err != nil {
+			return fmt.Errorf("Error writing zip: %v", err)
+		}
+		if err := f.write(file); err != nil {
+			return err
+		}
+	}
+
+	if err := bundle.Close(); err != nil {
+		return fmt.Errorf("Error writing zip: %v", err)
+	}
+	return nil
+}
+
+// export to a file, or stdout when path is empty
+func exportHistoryToFile(filePath string, format HistoryExportFormat, history *HistoryStore, entries []*TranscriptionResult) error {
+	if filePath == "" {
+		return exportHistory(os.Stdout, format, history, entries)
+	}
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("Error creating export file: %v", err)
+	}
+
+	if err := exportHistory(file, format, history, entries); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestExportSubtitlesFromTranscription(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{
+			"text": " Hello there. General Kenobi.",
+			"segments": [
+				{"id": 0, "start": 0.0, "end": 1.5, "text": " Hello there."},
+				{"id": 1, "start": 1.5, "end": 3661.0625, "text": " General Kenobi."}
+			]
+		}`)
+	}))
+	defer server.Close()
+
+	transcriber := &HTTPTranscriber{URL: server.URL, client: server.Client()}
+	result, err := transcriber.Transcribe(context.Background(), writeTestAudio(t), TranscriptionOptions{Segments: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"srt": "1\n00:00:00,000 --> 00:00:01,500\nHello there.\n\n" +
+			"2\n00:00:01,500 --> 01:01:01,063\nGeneral Kenobi.\n\n",
+		"vtt": "WEBVTT\n\n" +
+			"00:00:00.000 --> 00:00:01.500\nHello there.\n\n" +
+			"00:00:01.500 --> 01:01:01.063\nGeneral Kenobi.\n\n",
+	}
+
+	for name, subtitles := range expected {
+		format, err := getHistoryExportFormat(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var out bytes.Buffer
+		if err := exportHistory(&out, format, newHistoryStore(), []*TranscriptionResult{result}); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if out.String() != subtitles {
+			t.Errorf("%s:\n%s\nwant:\n%s", name, out.String(), subtitles)
+		}
+	}
+
+	format, _ := getHistoryExportFormat("srt")
+	if err := exportHistory(io.Discard, format, newHistoryStore(), []*TranscriptionResult{{UUID: "plain"}}); err == nil {
+		t.Error("expected an error for an entry without segments")
+	}
+}
+
+func TestExportHistoryZip(t *testing.T) {
+	history, err := openHistoryStore(t.TempDir(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	withAudio := NewTranscriptionResult()
+	withAudio.Original = "recorded"
+	withAudio.AudioRecording = []byte("MP3 DATA")
+	withAudio.AudioFormat = "mp3"
+	withAudio.Segments = []TranscriptionSegment{{Start: 0, End: 1, Text: " recorded"}}
+
+	withoutAudio := NewTranscriptionResult()
+	withoutAudio.Original = "typed"
+
+	for _, entry := range []*TranscriptionResult{withAudio, withoutAudio} {
+		if err := history.Add(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	format, _ := getHistoryExportFormat("zip")
+	var out bytes.Buffer
+	if err := exportHistory(&out, format, history, history.List()); err != nil {
+		t.Fatal(err)
+	}
+
+	bundle, err := zip.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := make(map[string]string)
+	var names []string
+	for _, file := range bundle.File {
+		reader, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := io.ReadAll(reader)
+		reader.Close()
+
+		files[file.Name] = string(content)
+		names = append(names, file.Name)
+
+		if strings.HasPrefix(file.Name, "audio/") && file.Method != zip.Store {
+			t.Errorf("%s is compressed", file.Name)
+		}
+	}
+
+	sort.Strings(names)
+	expectedNames := []string{
+		"audio/" + withAudio.UUID + ".mp3",
+		"history.csv",
+		"history.jsonl",
+		"history.md",
+		"subtitles/" + withAudio.UUID + ".srt",
+		"subtitles/" + withAudio.UUID + ".vtt",
+	}
+	if strings.Join(names, "\n") != strings.Join(expectedNames, "\n") {
+		t.Fatalf("zip has:\n%s\nwant:\n%s", strings.Join(names, "\n"), strings.Join(expectedNames, "\n"))
+	}
+
+	if files["audio/"+withAudio.UUID+".mp3"] != "MP3 DATA" {
+		t.Errorf("audio = %q", files["audio/"+withAudio.UUID+".mp3"])
+	}
+
+	// audio paths point inside the bundle, and only for included recordings
+	audioPaths := make(map[string]string)
+	decoder := json.NewDecoder(strings.NewReader(files["history.jsonl"]))
+	for decoder.More() {
+		var line struct {
+			UUID      string
+			AudioPath string
+		}
+		if err := decoder.Decode(&line); err != nil {
+			t.Fatal(err)
+		}
+		audioPaths[line.UUID] = line.AudioPath
+	}
+
+	if len(audioPaths) != 2 {
+		t.Fatalf("history.jsonl has %d entries, want 2", len(audioPaths))
+	}
+	if audioPath := audioPaths[withAudio.UUID]; audioPath != "audio/"+withAudio.UUID+".mp3" {
+		t.Errorf("AudioPath = %q", audioPath)
+	} else if _, ok := files[audioPath]; !ok {
+		t.Errorf("AudioPath %s isn't in the zip", audioPath)
+	}
+	if audioPath := audioPaths[withoutAudio.UUID]; audioPath != "" {
+		t.Errorf("AudioPath = %q for an entry without audio", audioPath)
+	}
+
+	if !strings.Contains(files["history.csv"], "audio/"+withAudio.UUID+".mp3") {
+		t.Errorf("history.csv doesn't link the recording:\n%s", files["history.csv"])
+	}
+	if !strings.Contains(files["subtitles/"+withAudio.UUID+".srt"], "recorded") {
+		t.Errorf("subtitles = %q", files["subtitles/"+withAudio.UUID+".srt"])
+	}
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"bufio"
+	"crypto/md5"
+	"encoding/binary"
+	"io"
+	"math/bits"
+)
+
+const flacBlockSize = 4096
+
+// FLACEncoder writes lossless FLAC using fixed linear predictors and rice
+// coded residuals. Not as small as the reference encoder, but still lossless
+// and noticeably smaller than WAV
+type FLACEncoder struct{}
+
+func (FLACEncoder) Format() string {
+	return "flac"
+}
+
+func (FLACEncoder) ContentType() string {
+	return "audio/flac"
+}
+
+func (FLACEncoder) Encode(w io.Writer, samples []int16, sampleRate int) error {
+	out := bufio.NewWriter(w)
+
+	if _, err := out.WriteString("fLaC"); err != nil {
+		return err
+	}
+
+	// STREAMINFO is the only metadata block
+	hash := md5.New()
+	binary.Write(hash, binary.LittleEndian, samples)
+
+	streamInfo := &flacBitWriter{}
+	streamInfo.write(1, 1) // last metadata block
+	streamInfo.write(0, 7) // STREAMINFO
+	streamInfo.write(34, 24)
+	streamInfo.write(flacBlockSize, 16) // min block size
+	streamInfo.write(flacBlockSize, 16) // max block size
+	streamInfo.write(0, 24)             // min frame size, unknown
+	streamInfo.write(0, 24)             // max frame size, unknown
+	streamInfo.write(uint64(sampleRate), 20)
+	streamInfo.write(0, 3)  // channels - 1
+	streamInfo.write(15, 5) // bits per sample - 1
+	streamInfo.write(uint64(len(samples)), 36)
+	streamInfo.bytes = append(streamInfo.bytes, hash.Sum(nil)...)
+
+	if _, err := out.Write(streamInfo.bytes); err != nil {
+		return err
+	}
+
+	for frameNumber, offset := 0, 0; offset < len(samples); frameNumber, offset = frameNumber+1, offset+flacBlockSize {
+		block := samples[offset:min(offset+flacBlockSize, len(samples))]
+		if _, err := out.Write(encodeFLACFrame(block, frameNumber)); err != nil {
+			return err
+		}
+	}
+
+	return out.Flush()
+}
+
+func encodeFLACFrame(block []int16, frameNumber int) []byte {
+	frame := &flacBitWriter{}
+
+	frame.write(0x3ffe, 14) // sync code
+	frame.write(0, 1)       // reserved
+	frame.write(0, 1)       // fixed block size stream
+	frame.write(7, 4)       // block size stored as 16 bits at the end of the header
+	frame.write(0, 4)       // sample rate from STREAMINFO
+	frame.write(0, 4)       // mono
+	frame.write(4, 3)       // 16 bits per sample
+	frame.write(0, 1)       // reserved
+	frame.writeUTF8(uint64(frameNumber))
+	frame.write(uint64(len(block)-1), 16)
+	frame.write(uint64(flacCRC8(frame.bytes)), 8)
+
+	writeFLACSubframe(frame, block)
+
+	frame.align()
+	frame.write(uint64(flacCRC16(frame.bytes)), 16)
+
+	return frame.bytes
+}
+
+// compute the residual of the fixed polynomial predictor of the given order
+func flacFixedResidual(block []int16, order int) []int32 {
+	residual := make([]int32, len(block)-order)
+	for i := order; i < len(block); i++ {
+		s := func(n int) int32 { return int32(block[i-n]) }
+		var prediction int32
+		switch order {
+		case 1:
+			prediction = s(1)
+		case 2:
+			prediction = 2*s(1) - s(2)
+		case 3:
+			prediction = 3*s(1) - 3*s(2) + s(3)
+		case 4:
+			prediction = 4*s(1) - 6*s(2) + 4*s(3) - s(4)
+		}
+		residual[i-order] = s(0) - prediction
+	}
+	return residual
+}
+
+// pick the rice parameter for the residual from its mean magnitude
+func flacRiceParameter(residual []int32) int {
+	if len(residual) == 0 {
+		return 0
+	}
+
+	var sum uint64
+	for _, r := range residual {
+		sum += uint64(flacZigZag(r))
+	}
+
+	mean := sum / uint64(len(residual))
+	if mean == 0 {
+		return 0
+	}
+	return min(bits.Len64(mean)-1, 14)
+}
+
+func flacZigZag(r int32) uint32 {
+	return uint32((r << 1) ^ (r >> 31))
+}
+
+// number of bits needed to rice code the residual with parameter k
+func flacRiceBits(residual []int32, k int) int {
+	total := 0
+	for _, r := range residual {
+		total += int(flacZigZag(r)>>k) + 1 + k
+	}
+	return total
+}
+
+func writeFLACSubframe(frame *flacBitWriter, block []int16) {
+	// use the fixed predictor order that gives the smallest residual
+	bestOrder := -1
+	bestBits := len(block) * 16 // verbatim
+	var bestResidual []int32
+	bestParameter := 0
+
+	for order := 0; order <= 4 && order < len(block); order++ {
+		residual := flacFixedResidual(block, order)
+		parameter := flacRiceParameter(residual)
+		size := order*16 + 2 + 4 + 4 + flacRiceBits(residual, parameter)
+		if size < bestBits {
+			bestOrder, bestBits = order, size
+			bestResidual, bestParameter = residual, parameter
+		}
+	}
+
+	frame.write(0, 1) // padding
+
+	if bestOrder < 0 {
+		frame.write(1, 6) // verbatim
+		frame.write(0, 1) // no wasted bits
+		for _, sample := range block {
+			frame.write(uint64(uint16(sample)), 16)
+		}
+		return
+	}
+
+	frame.write(uint64(8|bestOrder), 6) // fixed predictor
+	frame.write(0, 1)                   // no wasted bits
+
+	// warm up samples
+	for _, sample := range block[:bestOrder] {
+		frame.write(uint64(uint16(sample)), 16)
+	}
+
+	frame.write(0, 2) // rice coding with 4 bit parameters
+	frame.write(0, 4) // partition order 0, a single partition
+	frame.write(uint64(bestParameter), 4)
+
+	for _, r := range bestResidual {
+		value := flacZigZag(r)
+		frame.writeUnary(int(value >> bestParameter))
+		frame.write(uint64(value), bestParameter)
+	}
+}
+
+// flacBitWriter accumulates big endian bits into bytes
+type flacBitWriter struct {
+	bytes []byte
+	bits  uint8 // bits used in the last byte, 0 means byte aligned
+}
+
+// write the low n bits of value
+func (b *flacBitWriter) write(value uint64, n int) {
+	for n > 0 {
+		if b.bits == 0 {
+			b.bytes = append(b.bytes, 0)
+		}
+
+		free := int(8 - b.bits)
+		take := min(free, n)
+		chunk := (value >> (n - take)) & (1<<take - 1)
+		b.bytes[len(b.bytes)-1] |= byte(chunk << (free - take))
+
+		b.bits = uint8((int(b.bits) + take) % 8)
+		n -= take
+	}
+}
+
+// write n zero bits followed by a one
+func (b *flacBitWriter) writeUnary(n int) {
+	for ; n >= 32; n -= 32 {
+		b.write(0, 32)
+	}
+	b.write(1, n+1)
+}
+
+// pad with zeros to the next byte boundary
+func (b *flacBitWriter) align() {
+	b.bits = 0
+}
+
+// frame numbers are coded like UTF-8 characters
+func (b *flacBitWriter) writeUTF8(value uint64) {
+	if value < 0x80 {
+		b.write(value, 8)
+		return
+	}
+
+	extraBytes := 1
+	for value >= 1<<(5*extraBytes+6) {
+		extraBytes++
+	}
+
+	lead := uint64(0xff00>>(extraBytes+1)) & 0xff
+	b.write(lead|value>>(6*extraBytes), 8)
+	for i := extraBytes - 1; i >= 0; i-- {
+		b.write(0x80|(value>>(6*i))&0x3f, 8)
+	}
+}
+
+func flacCRC8(data []byte) uint8 {
+	var crc uint8
+	for _, d := range data {
+		crc ^= d
+		for i := 0; i < 8; i++ {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ 0x07
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+func flacCRC16(data []byte) uint16 {
+	var crc uint16
+	for _, d := range data {
+		crc ^= uint16(d) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x8005
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/binary"
+	"testing"
+)
+
+// reads big endian bits, the counterpart of flacBitWriter
+type flacBitReader struct {
+	data []byte
+	pos  int // in bits
+}
+
+func (r *flacBitReader) read(n int) uint64 {
+	var value uint64
+	for ; n > 0; n-- {
+		if r.pos/8 >= len(r.data) {
+			panic("read past the end of the FLAC data")
+		}
+		value = value<<1 | uint64(r.data[r.pos/8]>>(7-r.pos%8)&1)
+		r.pos++
+	}
+	return value
+}
+
+func (r *flacBitReader) align() {
+	r.pos = (r.pos + 7) / 8 * 8
+}
+
+// decode FLAC written by FLACEncoder, checking the header fields, CRCs and
+// MD5 along the way. Only handles the subframe types the encoder writes
+func decodeTestFLAC(t *testing.T, data []byte) ([]int16, int) {
+	t.Helper()
+
+	if len(data) < 42 || string(data[:4]) != "fLaC" {
+		t.Fatalf("missing FLAC header: %x", data[:min(len(data), 8)])
+	}
+
+	r := &flacBitReader{data: data, pos: 32}
+	if last, blockType, length := r.read(1), r.read(7), r.read(24); last != 1 || blockType != 0 || length != 34 {
+		t.Fatalf("metadata block: last %d, type %d, length %d", last, blockType, length)
+	}
+	if minBlock, maxBlock := r.read(16), r.read(16); minBlock != flacBlockSize || maxBlock != flacBlockSize {
+		t.Errorf("block sizes %d to %d, want %d", minBlock, maxBlock, flacBlockSize)
+	}
+	r.read(48) // frame sizes
+	sampleRate := int(r.read(20))
+	if channels, bitsPerSample := r.read(3)+1, r.read(5)+1; channels != 1 || bitsPerSample != 16 {
+		t.Fatalf("%d channels of %d bits, want mono 16 bit", channels, bitsPerSample)
+	}
+	totalSamples := int(r.read(36))
+	checksum := data[r.pos/8 : r.pos/8+16]
+	r.pos += 128
+
+	var samples []int16
+	for frameNumber := 0; r.pos/8 < len(data); frameNumber++ {
+		start := r.pos / 8
+
+		if sync := r.read(14); sync != 0x3ffe {
+			t.Fatalf("frame %d: sync code %#x", frameNumber, sync)
+		}
+		r.read(2)
+		if blockSizeCode := r.read(4); blockSizeCode != 7 {
+			t.Fatalf("frame %d: block size code %d", frameNumber, blockSizeCode)
+		}
+		r.read(12) // sample rate, channels, sample size and reserved bit
+
+		// UTF-8 coded frame number
+		lead := r.read(8)
+		number := lead
+		if lead >= 0x80 {
+			extraBytes := 0
+			for mask := uint64(0x40); lead&mask != 0; mask >>= 1 {
+				extraBytes++
+			}
+			number = lead & (0x3f >> extraBytes)
+			for i := 0; i < extraBytes; i++ {
+				number = number<<6 | r.read(8)&0x3f
+			}
+		}
+		if number != uint64(frameNumber) {
+			t.Fatalf("frame %d: numbered %d", frameNumber, number)
+		}
+
+		blockSize := int(r.read(16)) + 1
+		if crc := uint8(r.read(8)); crc != flacCRC8(data[start:r.pos/8-1]) {
+			t.Fatalf("frame %d: header CRC-8 mismatch", frameNumber)
+		}
+
+		r.read(1) // padding
+		subframeType := r.read(6)
+		if wasted := r.read(1); wasted != 0 {
+			t.Fatalf("frame %d: wasted bits", frameNumber)
+		}
+
+		block := make([]int32, blockSize)
+		switch {
+		case subframeType == 1: // verbatim
+			for i := range block {
+				block[i] = int32(int16(r.read(16)))
+			}
+		case subframeType&0x38 == 8: // fixed predictor
+			order := int(subframeType & 7)
+			for i := 0; i < order; i++ {
+				block[i] = int32(int16(r.read(16)))
+			}
+			if method, partitionOrder := r.read(2), r.read(4); method != 0 || partitionOrder != 0 {
+				t.Fatalf("frame %d: residual coding %d with partition order %d", frameNumber, method, partitionOrder)
+			}
+			parameter := int(r.read(4))
+
+			residual := make([]int32, 0, blockSize-order)
+			for i := order; i < blockSize; i++ {
+				quotient := 0
+				for r.read(1) == 0 {
+					quotient++
+				}
+				value := uint32(quotient)<<parameter | uint32(r.read(parameter))
+				residual = append(residual, int32(value>>1)^-int32(value&1))
+			}
+
+			// undo the prediction, the inverse of flacFixedResidual
+			for i := order; i < blockSize; i++ {
+				s := func(n int) int32 { return block[i-n] }
+				var prediction int32
+				switch order {
+				case 1:
+					prediction = s(1)
+				case 2:
+					prediction = 2*s(1) - s(2)
+				case 3:
+					prediction = 3*s(1) - 3*s(2) + s(3)
+				case 4:
+					prediction = 4*s(1) - 6*s(2) + 4*s(3) - s(4)
+				}
+				block[i] = residual[i-order] + prediction
+			}
+		default:
+			t.Fatalf("frame %d: unexpected subframe type %#x", frameNumber, subframeType)
+		}
+
+		r.align()
+		if crc := uint16(r.read(16)); crc != flacCRC16(data[start:r.pos/8-2]) {
+			t.Fatalf("frame %d: CRC-16 mismatch", frameNumber)
+		}
+
+		for _, sample := range block {
+			samples = append(samples, int16(sample))
+		}
+	}
+
+	if len(samples) != totalSamples {
+		t.Errorf("decoded %d samples, STREAMINFO says %d", len(samples), totalSamples)
+	}
+
+	hash := md5.New()
+	binary.Write(hash, binary.LittleEndian, samples)
+	if !bytes.Equal(hash.Sum(nil), checksum) {
+		t.Error("MD5 of the decoded samples doesn't match STREAMINFO")
+	}
+
+	return samples, sampleRate
+}
+
+func TestFLACEncoderRoundTrip(t *testing.T) {
+	// full scale square wave, with runs that clip at both ends
+	clipped := make([]int16, 10000)
+	for i := range clipped {
+		if i/10%2 == 0 {
+			clipped[i] = 32767
+		} else {
+			clipped[i] = -32768
+		}
+	}
+
+	// alternating full scale samples, which no fixed predictor helps with
+	alternating := make([]int16, 5000)
+	for i := range alternating {
+		alternating[i] = int16(-32768 + 65535*(i%2))
+	}
+
+	// signals each fixed predictor order reproduces exactly
+	constant := make([]int16, 5000)
+	ramp := make([]int16, 5000)
+	for i := range constant {
+		constant[i] = 1000
+		ramp[i] = int16(i*6 - 15000)
+	}
+	quadratic := make([]int16, 361)
+	for i := range quadratic {
+		quadratic[i] = int16((i - 180) * (i - 180))
+	}
+
+	tests := []struct {
+		name    string
+		samples []int16
+	}{
+		{"empty", nil},
+		{"one sample", []int16{-12345}},
+		{"silence", make([]int16, 16000)},
+		{"constant", constant},
+		{"ramp", ramp},
+		{"quadratic", quadratic},
+		{"sine", sineWave(16000, 440)},
+		{"clipped", clipped},
+		{"alternating", alternating},
+		// more than 128 frames, so frame numbers take two bytes
+		{"long", make([]int16, 130*flacBlockSize+1)},
+	}
+
+	for _, test := range tests {
+		var out bytes.Buffer
+		if err := (FLACEncoder{}).Encode(&out, test.samples, 16000); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		samples, sampleRate := decodeTestFLAC(t, out.Bytes())
+		if sampleRate != 16000 {
+			t.Errorf("%s: sample rate %d", test.name, sampleRate)
+		}
+		if len(samples) != len(test.samples) {
+			t.Errorf("%s: decoded %d samples, want %d", test.name, len(samples), len(test.samples))
+			continue
+		}
+		for i := range samples {
+			if samples[i] != test.samples[i] {
+				t.Errorf("%s: sample %d is %d, want %d", test.name, i, samples[i], test.samples[i])
+				break
+			}
+		}
+
+		// anything predictable should compress well below 16 bits a sample
+		if test.name == "silence" || test.name == "sine" {
+			if out.Len() > len(test.samples) {
+				t.Errorf("%s: %d bytes for %d samples", test.name, out.Len(), len(test.samples))
+			}
+		}
+	}
+}
+
+func TestFLACCRC(t *testing.T) {
+	// the standard check values for the polynomials FLAC uses
+	if crc := flacCRC8([]byte("123456789")); crc != 0xf4 {
+		t.Errorf("CRC-8 = %#x, want 0xf4", crc)
+	}
+	if crc := flacCRC16([]byte("123456789")); crc != 0xfee8 {
+		t.Errorf("CRC-16 = %#x, want 0xfee8", crc)
+	}
+}
@@ -0,0 +1,638 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const historyIndexName = "history.jsonl"
+
+// held while reading or changing the index, so a compaction in one process
+// doesn't drop records another is appending
+const historyLockName = "history.lock"
+
+// one line of the history index, either a new or updated entry, or the
+// removal of one. The last record for a UUID wins
+type historyRecord struct {
+	Entry   *TranscriptionResult `json:",omitempty"`
+	Deleted string               `json:",omitempty"`
+}
+
+// HistoryStore keeps transcriptions on disk so they survive restarts. Records
+// are appended to a JSONL index, and audio is saved next to it as
+// audio/<uuid>.<format>. Other processes can append to the same index, their
+// records are picked up the next time the store is read
+type HistoryStore struct {
+	// empty keeps the history in memory only
+	dir string
+	// for reading the history without changing it, eg. to export it
+	readOnly bool
+
+	mu sync.Mutex
+	// oldest first, audio isn't held in memory unless there's no dir
+	entries    []*TranscriptionResult
+	byUUID     map[string]*TranscriptionResult
+	audioSizes map[string]int64
+
+	// the index file that has been read, how far into it, and how many
+	// records it has
+	indexInfo os.FileInfo
+	offset    int64
+	records   int
+}
+
+// a store that isn't saved anywhere
+func newHistoryStore() *HistoryStore {
+	return &HistoryStore{
+		byUUID:     make(map[string]*TranscriptionResult),
+		audioSizes: make(map[string]int64),
+	}
+}
+
+// open the store in dir, loading the existing history and applying retention.
+// A read only store leaves the history as it is, and can't be changed
+func openHistoryStore(dir string, readOnly bool) (*HistoryStore, error) {
+	if err := os.MkdirAll(filepath.Join(dir, "audio"), 0700); err != nil {
+		return nil, fmt.Errorf("Error creating history directory: %v", err)
+	}
+
+	s := newHistoryStore()
+	s.dir = dir
+	s.readOnly = readOnly
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := s.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if err := s.refresh(); err != nil {
+		return nil, err
+	}
+
+	if readOnly {
+		log.Printf("Loaded %d history entries from %s\n", len(s.entries), dir)
+		return s, nil
+	}
+
+	if err := s.applyRetention(); err != nil {
+		return nil, err
+	}
+
+	// drop the records of removed and replaced entries
+	if s.records > len(s.entries) {
+		if err := s.compact(); err != nil {
+			return nil, err
+		}
+	}
+
+	log.Printf("Loaded %d history entries from %s\n", len(s.entries), dir)
+	return s, nil
+}
+
+// add an entry to the history, saving its audio
+func (s *HistoryStore) Add(entry *TranscriptionResult) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := s.lockForWrite()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	stored := *entry
+
+	if s.dir != "" {
+		stored.AudioRecording = nil
+
+		if entry.AudioRecording != nil {
+			if err := os.WriteFile(s.audioPath(&stored), entry.AudioRecording, 0600); err != nil {
+				return fmt.Errorf("Error writing history audio: %v", err)
+			}
+		}
+	}
+
+	if err := s.write(historyRecord{Entry: &stored}); err != nil {
+		return err
+	}
+
+	return s.applyRetention()
+}
+
+// all entries, oldest first
+func (s *HistoryStore) List() []*TranscriptionResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.reload(); err != nil {
+		log.Printf("%v\n", err)
+	}
+
+	return append([]*TranscriptionResult(nil), s.entries...)
+}
+
+// the entry with the UUID, or nil
+func (s *HistoryStore) Get(uuid string) *TranscriptionResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.reload(); err != nil {
+		log.Printf("%v\n", err)
+	}
+
+	return s.byUUID[uuid]
+}
+
+// HistoryQuery filters and pages through the history
+type HistoryQuery struct {
+	// the most entries to return, 0 for all
+	Limit int
+	// only entries older than the entry with this UUID, for fetching the next
+	// page
+	Before string
+	// words that must all appear in the transcription, case insensitive
+	Text string
+	// only entries created in this range, a zero time leaves that end open
+	From time.Time
+	To   time.Time
+	// only entries where processing failed
+	Failed bool
+}
+
+func (q HistoryQuery) Matches(entry *TranscriptionResult) bool {
+	if q.Failed && entry.Error == "" {
+		return false
+	}
+
+	if !q.From.IsZero() && entry.CreatedAt.Before(q.From) {
+		return false
+	}
+
+	if !q.To.IsZero() && entry.CreatedAt.After(q.To) {
+		return false
+	}
+
+	if q.Text != "" {
+		text := strings.ToLower(entry.Original + "\n" + entry.Modified)
+		for _, word := range strings.Fields(strings.ToLower(q.Text)) {
+			if !strings.Contains(text, word) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// the entries matching the query, newest first, and whether there are more
+// past the limit
+func (s *HistoryStore) Search(query HistoryQuery) ([]*TranscriptionResult, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.reload(); err != nil {
+		log.Printf("%v\n", err)
+	}
+
+	start := len(s.entries) - 1
+	if query.Before != "" {
+		start = s.find(query.Before) - 1
+	}
+
+	results := []*TranscriptionResult{}
+	for i := start; i >= 0; i-- {
+		if !query.Matches(s.entries[i]) {
+			continue
+		}
+
+		if query.Limit > 0 && len(results) == query.Limit {
+			return results, true
+		}
+		results = append(results, s.entries[i])
+	}
+
+	return results, false
+}
+
+// remove entries and their audio, returns how many were removed
+func (s *HistoryStore) Delete(uuids ...string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := s.lockForWrite()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	if err := s.refresh(); err != nil {
+		return 0, err
+	}
+
+	removed := make(map[string]bool)
+	for _, uuid := range uuids {
+		if s.byUUID[uuid] != nil {
+			removed[uuid] = true
+		}
+	}
+
+	if len(removed) == 0 {
+		return 0, nil
+	}
+
+	return len(removed), s.remove(removed)
+}
+
+// remove every entry, returns how many were removed
+func (s *HistoryStore) Clear() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := s.lockForWrite()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	if err := s.refresh(); err != nil {
+		return 0, err
+	}
+
+	removed := make(map[string]bool)
+	for _, entry := range s.entries {
+		removed[entry.UUID] = true
+	}
+
+	if err := s.remove(removed); err != nil {
+		return 0, err
+	}
+
+	if s.dir != "" {
+		return len(removed), s.compact()
+	}
+	return len(removed), nil
+}
+
+// the recorded audio of an entry
+func (s *HistoryStore) ReadAudio(entry *TranscriptionResult) ([]byte, error) {
+	if s.dir == "" {
+		if entry.AudioRecording == nil {
+			return nil, os.ErrNotExist
+		}
+		return entry.AudioRecording, nil
+	}
+
+	if entry.AudioFormat == "" {
+		return nil, os.ErrNotExist
+	}
+
+	return os.ReadFile(s.audioPath(entry))
+}
+
+// the path of an entry's recording on disk, or empty if it isn't saved
+func (s *HistoryStore) AudioFile(entry *TranscriptionResult) string {
+	if s.dir == "" || entry.AudioFormat == "" {
+		return ""
+	}
+	return s.audioPath(entry)
+}
+
+func (s *HistoryStore) find(uuid string) int {
+	for i, entry := range s.entries {
+		if entry.UUID == uuid {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *HistoryStore) indexPath() string {
+	return filepath.Join(s.dir, historyIndexName)
+}
+
+func (s *HistoryStore) audioPath(entry *TranscriptionResult) string {
+	return filepath.Join(s.dir, "audio", entry.UUID+"."+entry.AudioFormat)
+}
+
+// take the lock shared with other processes using the directory, s.mu must
+// already be held. Returns the function that releases it
+func (s *HistoryStore) lock() (func(), error) {
+	if s.dir == "" {
+		return func() {}, nil
+	}
+
+	lockFile, err := os.OpenFile(filepath.Join(s.dir, historyLockName), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("Error opening history lock: %v", err)
+	}
+
+	if err := lockHistoryFile(lockFile); err != nil {
+		lockFile.Close()
+		return nil, fmt.Errorf("Error locking history: %v", err)
+	}
+
+	return func() {
+		unlockHistoryFile(lockFile)
+		lockFile.Close()
+	}, nil
+}
+
+// the lock for changing the history, which a read only store can't do
+func (s *HistoryStore) lockForWrite() (func(), error) {
+	if s.readOnly {
+		return nil, fmt.Errorf("History is open read only")
+	}
+	return s.lock()
+}
+
+// read any records added since the index was last read
+func (s *HistoryStore) reload() error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return s.refresh()
+}
+
+// save records. On disk they're appended to the index and then read back, so
+// records from other processes are applied in the same order. The lock must
+// be held
+func (s *HistoryStore) write(records ...historyRecord) error {
+	if s.dir == "" {
+		for _, record := range records {
+			s.apply(record)
+		}
+		return nil
+	}
+
+	var lines []byte
+	for _, record := range records {
+		line, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("Error encoding history record: %v", err)
+		}
+		lines = append(append(lines, line...), '\n')
+	}
+
+	indexFile, err := os.OpenFile(s.indexPath(), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("Error opening history index: %v", err)
+	}
+
+	// a single append so concurrent writers don't interleave lines
+	_, err = indexFile.Write(lines)
+	closeErr := indexFile.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("Error writing history index: %v", err)
+	}
+
+	return s.refresh()
+}
+
+// read any records added to the index since it was last read. If the index
+// was replaced by a compaction it's read again from the start
+func (s *HistoryStore) refresh() error {
+	if s.dir == "" {
+		return nil
+	}
+
+	indexFile, err := os.Open(s.indexPath())
+	if os.IsNotExist(err) {
+		s.reset()
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("Error opening history index: %v", err)
+	}
+	defer indexFile.Close()
+
+	info, err := indexFile.Stat()
+	if err != nil {
+		return fmt.Errorf("Error reading history index: %v", err)
+	}
+
+	if s.indexInfo == nil || !os.SameFile(info, s.indexInfo) || info.Size() < s.offset {
+		s.reset()
+	}
+	s.indexInfo = info
+
+	if info.Size() == s.offset {
+		return nil
+	}
+
+	if _, err := indexFile.Seek(s.offset, io.SeekStart); err != nil {
+		return fmt.Errorf("Error reading history index: %v", err)
+	}
+
+	reader := bufio.NewReader(indexFile)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			// a partial line is still being written
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("Error reading history index: %v", err)
+		}
+
+		s.offset += int64(len(line))
+		s.records++
+
+		var record historyRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			log.Printf("Skipping invalid history record: %v\n", err)
+			continue
+		}
+		s.apply(record)
+	}
+
+	return nil
+}
+
+func (s *HistoryStore) reset() {
+	s.entries = nil
+	s.byUUID = make(map[string]*TranscriptionResult)
+	s.audioSizes = make(map[string]int64)
+	s.indexInfo = nil
+	s.offset = 0
+	s.records = 0
+}
+
+func (s *HistoryStore) apply(record historyRecord) {
+	if record.Deleted != "" {
+		if i := s.find(record.Deleted); i >= 0 {
+			s.entries = append(s.entries[:i], s.entries[i+1:]...)
+		}
+		delete(s.byUUID, record.Deleted)
+		delete(s.audioSizes, record.Deleted)
+		return
+	}
+
+	entry := record.Entry
+	if entry == nil || entry.UUID == "" {
+		return
+	}
+
+	if _, ok := s.byUUID[entry.UUID]; ok {
+		s.entries[s.find(entry.UUID)] = entry
+	} else {
+		s.entries = append(s.entries, entry)
+	}
+	s.byUUID[entry.UUID] = entry
+
+	if s.dir == "" {
+		s.audioSizes[entry.UUID] = int64(len(entry.AudioRecording))
+	} else if entry.AudioFormat != "" {
+		if info, err := os.Stat(s.audioPath(entry)); err == nil {
+			s.audioSizes[entry.UUID] = info.Size()
+		}
+	}
+}
+
+// remove the oldest entries that are past the age, count or size limits
+func (s *HistoryStore) applyRetention() error {
+	removed := make(map[string]bool)
+
+	if config.HistoryMaxAgeDays > 0 {
+		cutoff := time.Now().AddDate(0, 0, -config.HistoryMaxAgeDays)
+		for _, entry := range s.entries {
+			if !entry.CreatedAt.IsZero() && entry.CreatedAt.Before(cutoff) {
+				removed[entry.UUID] = true
+			}
+		}
+	}
+
+	if config.HistoryMaxEntries > 0 {
+		for i := 0; i < len(s.entries)-config.HistoryMaxEntries; i++ {
+			removed[s.entries[i].UUID] = true
+		}
+	}
+
+	if config.HistoryMaxMB > 0 {
+		var totalSize int64
+		for _, entry := range s.entries {
+			if !removed[entry.UUID] {
+				totalSize += s.audioSizes[entry.UUID]
+			}
+		}
+
+		maxSize := int64(config.HistoryMaxMB) * 1024 * 1024
+		for _, entry := range s.entries {
+			if totalSize <= maxSize {
+				break
+			}
+			if !removed[entry.UUID] {
+				removed[entry.UUID] = true
+				totalSize -= s.audioSizes[entry.UUID]
+			}
+		}
+	}
+
+	if len(removed) == 0 {
+		return nil
+	}
+
+	return s.remove(removed)
+}
+
+// remove entries and their audio
+func (s *HistoryStore) remove(uuids map[string]bool) error {
+	var records []historyRecord
+	var audioPaths []string
+
+	for _, entry := range s.entries {
+		if uuids[entry.UUID] {
+			records = append(records, historyRecord{Deleted: entry.UUID})
+			if s.dir != "" && entry.AudioFormat != "" {
+				audioPaths = append(audioPaths, s.audioPath(entry))
+			}
+		}
+	}
+
+	if err := s.write(records...); err != nil {
+		return err
+	}
+
+	for _, audioPath := range audioPaths {
+		if err := os.Remove(audioPath); err != nil && !os.IsNotExist(err) {
+			log.Printf("Error removing history audio: %v\n", err)
+		}
+	}
+
+	// keep the index from growing forever with removed entries
+	if s.dir != "" && s.records > 2*len(s.entries)+100 {
+		return s.compact()
+	}
+
+	return nil
+}
+
+// rewrite the index with only the current entries, and remove audio files
+// that don't belong to any entry. The lock must be held from when the entries
+// were read so no other process appends in between
+func (s *HistoryStore) compact() error {
+	tempFile, err := os.CreateTemp(s.dir, historyIndexName+".*")
+	if err != nil {
+		return fmt.Errorf("Error compacting history: %v", err)
+	}
+	defer os.Remove(tempFile.Name())
+
+	writer := bufio.NewWriter(tempFile)
+	encoder := json.NewEncoder(writer)
+	for _, entry := range s.entries {
+		if err := encoder.Encode(historyRecord{Entry: entry}); err != nil {
+			tempFile.Close()
+			return fmt.Errorf("Error compacting history: %v", err)
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		tempFile.Close()
+		return fmt.Errorf("Error compacting history: %v", err)
+	}
+
+	if err := tempFile.Close(); err != nil {
+		return fmt.Errorf("Error compacting history: %v", err)
+	}
+
+	if err := os.Rename(tempFile.Name(), s.indexPath()); err != nil {
+		return fmt.Errorf("Error compacting history: %v", err)
+	}
+
+	// recent files may belong to an entry another process is adding
+	cutoff := time.Now().Add(-time.Minute)
+
+	audioFiles, err := os.ReadDir(filepath.Join(s.dir, "audio"))
+	if err == nil {
+		for _, audioFile := range audioFiles {
+			uuid := strings.TrimSuffix(audioFile.Name(), filepath.Ext(audioFile.Name()))
+			if s.byUUID[uuid] != nil {
+				continue
+			}
+
+			if info, err := audioFile.Info(); err == nil && info.ModTime().Before(cutoff) {
+				os.Remove(filepath.Join(s.dir, "audio", audioFile.Name()))
+			}
+		}
+	}
+
+	s.reset()
+	return s.refresh()
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newHistoryEntry(uuid string) *TranscriptionResult {
+	return &TranscriptionResult{UUID: uuid, CreatedAt: time.Now(), Original: "text " + uuid}
+}
+
+// compact the index the way removing entries eventually does
+func compactHistory(s *HistoryStore) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := s.refresh(); err != nil {
+		return err
+	}
+	return s.compact()
+}
+
+func TestHistoryConcurrentCompaction(t *testing.T) {
+	dir := t.TempDir()
+
+	// two stores on the same directory stand in for two processes, one adding
+	// entries while the other keeps removing its own and compacting the index
+	adding, err := openHistoryStore(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compacting, err := openHistoryStore(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const count = 200
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+		for i := 0; i < count; i++ {
+			if err := adding.Add(newHistoryEntry(fmt.Sprintf("kept-%d", i))); err != nil {
+				t.Errorf("adding: %v", err)
+				return
+			}
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		for i := 0; i < count; i++ {
+			uuid := fmt.Sprintf("removed-%d", i)
+			if err := compacting.Add(newHistoryEntry(uuid)); err != nil {
+				t.Errorf("adding: %v", err)
+				return
+			}
+			if _, err := compacting.Delete(uuid); err != nil {
+				t.Errorf("deleting: %v", err)
+				return
+			}
+			if i%10 == 0 {
+				if err := compactHistory(compacting); err != nil {
+					t.Errorf("compacting: %v", err)
+					return
+				}
+			}
+		}
+	}()
+
+	wg.Wait()
+
+	reopened, err := openHistoryStore(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries := reopened.List()
+	if len(entries) != count {
+		t.Errorf("got %d entries, want %d", len(entries), count)
+	}
+	for i := 0; i < count; i++ {
+		if reopened.Get(fmt.Sprintf("kept-%d", i)) == nil {
+			t.Errorf("entry kept-%d was lost", i)
+		}
+	}
+}
+
+func TestHistoryReadOnly(t *testing.T) {
+	oldConfig := config
+	t.Cleanup(func() { config = oldConfig })
+	config.HistoryMaxEntries = 0
+
+	dir := t.TempDir()
+	store, err := openHistoryStore(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := store.Add(newHistoryEntry(fmt.Sprintf("entry-%d", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	store.Delete("entry-0")
+
+	indexPath := filepath.Join(dir, historyIndexName)
+	before, err := os.ReadFile(indexPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// past the retention limit, and with a removed entry to compact away
+	config.HistoryMaxEntries = 1
+	readOnly, err := openHistoryStore(dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if entries := readOnly.List(); len(entries) != 2 {
+		t.Errorf("got %d entries, want 2", len(entries))
+	}
+
+	after, err := os.ReadFile(indexPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(after) != string(before) {
+		t.Errorf("opening read only changed the index:\n%s", after)
+	}
+
+	if err := readOnly.Add(newHistoryEntry("entry-3")); err == nil {
+		t.Error("expected an error adding to a read only store")
+	}
+	if _, err := readOnly.Delete("entry-1"); err == nil {
+		t.Error("expected an error deleting from a read only store")
+	}
+	if _, err := readOnly.Clear(); err == nil {
+		t.Error("expected an error clearing a read only store")
+	}
+}
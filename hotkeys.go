@@ -0,0 +1,315 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"golang.design/x/hotkey"
+)
+
+// actions that can be bound in the Hotkeys config
+const (
+	HotkeyActionToggle           = "toggle"
+	HotkeyActionAbort            = "abort"
+	HotkeyActionPushToTalk       = "push-to-talk"
+	HotkeyActionRecordWithScreen = "record-with-screen"
+	HotkeyActionRecordRaw        = "record-raw"
+	HotkeyActionRetypeLast       = "retype-last"
+	HotkeyActionUndoLast         = "undo-last"
+	// only registered in -one-shot mode
+	HotkeyActionOneShotStop = "one-shot-stop"
+)
+
+// the actions registered while running in the tray, in the order conflicts are
+// resolved: when two actions share a combination the first one keeps it
+var trayHotkeyActions = []string{
+	HotkeyActionToggle,
+	HotkeyActionAbort,
+	HotkeyActionPushToTalk,
+	HotkeyActionRecordWithScreen,
+	HotkeyActionRecordRaw,
+	HotkeyActionRetypeLast,
+	HotkeyActionUndoLast,
+}
+
+// values for RecordHotkeyMode
+const (
+	HotkeyModeToggle     = "toggle"
+	HotkeyModePushToTalk = "push-to-talk"
+)
+
+// key names for hotkey combinations, the modifiers are in the per OS
+// hotkeyModifiers
+var hotkeyKeys = map[string]hotkey.Key{
+	"space":  hotkey.KeySpace,
+	"return": hotkey.KeyReturn,
+	"enter":  hotkey.KeyReturn,
+	"escape": hotkey.KeyEscape,
+	"esc":    hotkey.KeyEscape,
+	"delete": hotkey.KeyDelete,
+	"tab":    hotkey.KeyTab,
+	"left":   hotkey.KeyLeft,
+	"right":  hotkey.KeyRight,
+	"up":     hotkey.KeyUp,
+	"down":   hotkey.KeyDown,
+}
+
+func init() {
+	letters := []hotkey.Key{
+		hotkey.KeyA, hotkey.KeyB, hotkey.KeyC, hotkey.KeyD, hotkey.KeyE, hotkey.KeyF,
+		hotkey.KeyG, hotkey.KeyH, hotkey.KeyI, hotkey.KeyJ, hotkey.KeyK, hotkey.KeyL,
+		hotkey.KeyM, hotkey.KeyN, hotkey.KeyO, hotkey.KeyP, hotkey.KeyQ, hotkey.KeyR,
+		hotkey.KeyS, hotkey.KeyT, hotkey.KeyU, hotkey.KeyV, hotkey.KeyW, hotkey.KeyX,
+		hotkey.KeyY, hotkey.KeyZ,
+	}
+	for i, key := range letters {
+		hotkeyKeys[string(rune('a'+i))] = key
+	}
+
+	digits := []hotkey.Key{
+		hotkey.Key0, hotkey.Key1, hotkey.Key2, hotkey.Key3, hotkey.Key4,
+		hotkey.Key5, hotkey.Key6, hotkey.Key7, hotkey.Key8, hotkey.Key9,
+	}
+	for i, key := range digits {
+		hotkeyKeys[string(rune('0'+i))] = key
+	}
+
+	functionKeys := []hotkey.Key{
+		hotkey.KeyF1, hotkey.KeyF2, hotkey.KeyF3, hotkey.KeyF4, hotkey.KeyF5,
+		hotkey.KeyF6, hotkey.KeyF7, hotkey.KeyF8, hotkey.KeyF9, hotkey.KeyF10,
+		hotkey.KeyF11, hotkey.KeyF12, hotkey.KeyF13, hotkey.KeyF14, hotkey.KeyF15,
+		hotkey.KeyF16, hotkey.KeyF17, hotkey.KeyF18, hotkey.KeyF19, hotkey.KeyF20,
+	}
+	for i, key := range functionKeys {
+		hotkeyKeys[fmt.Sprintf("f%d", i+1)] = key
+	}
+}
+
+// parse a combination like "ctrl+shift+space", names are case insensitive and
+// the key must come last
+func parseHotkey(spec string) ([]hotkey.Modifier, hotkey.Key, error) {
+	parts := strings.Split(strings.ToLower(spec), "+")
+
+	var mods []hotkey.Modifier
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			return nil, 0, fmt.Errorf("Invalid hotkey '%s'", spec)
+		}
+
+		if i == len(parts)-1 {
+			key, ok := hotkeyKeys[part]
+			if !ok {
+				return nil, 0, fmt.Errorf("Unknown key '%s' in hotkey '%s'", part, spec)
+			}
+			return mods, key, nil
+		}
+
+		mod, ok := hotkeyModifiers[part]
+		if !ok {
+			return nil, 0, fmt.Errorf("Unknown modifier '%s' in hotkey '%s'", part, spec)
+		}
+		mods = append(mods, mod)
+	}
+
+	return nil, 0, fmt.Errorf("Invalid hotkey '%s'", spec)
+}
+
+// identifies a combination regardless of how it was written, for finding
+// conflicts
+func hotkeyCombination(mods []hotkey.Modifier, key hotkey.Key) uint64 {
+	var mask hotkey.Modifier
+	for _, mod := range mods {
+		mask |= mod
+	}
+	return uint64(mask)<<32 | uint64(key)
+}
+
+// HotkeyBinding is an action from the Hotkeys config and the state of its
+// registration
+type HotkeyBinding struct {
+	Action string
+	// the combination as written in the config
+	Keys   string
+	Active bool
+	// why the binding isn't active
+	Error string `json:",omitempty"`
+
+	hotkey *hotkey.Hotkey
+}
+
+// a hotkey was pressed or released
+type hotkeyEvent struct {
+	Action  string
+	Pressed bool
+}
+
+// the bindings from the last call to registerHotkeys
+var hotkeyBindings atomic.Pointer[[]*HotkeyBinding]
+
+func getHotkeyBindings() []*HotkeyBinding {
+	bindings := hotkeyBindings.Load()
+	if bindings == nil {
+		return []*HotkeyBinding{}
+	}
+	return *bindings
+}
+
+// register the hotkeys configured for actions and send their presses and
+// releases to events. Bindings that can't be used, because they are invalid,
+// conflict with an earlier action, or can't be registered, are logged and
+// returned with an Error
+func registerHotkeys(actions []string, events chan<- hotkeyEvent) []*HotkeyBinding {
+	var bindings []*HotkeyBinding
+	combinations := map[uint64]*HotkeyBinding{}
+
+	known := map[string]bool{HotkeyActionOneShotStop: true}
+	for _, action := range trayHotkeyActions {
+		known[action] = true
+	}
+
+	for action, keys := range config.Hotkeys {
+		if !known[action] && keys != "" {
+			log.Printf("Hotkey %s: Unknown action '%s'\n", keys, action)
+			bindings = append(bindings, &HotkeyBinding{
+				Action: action,
+				Keys:   keys,
+				Error:  "Unknown action",
+			})
+		}
+	}
+
+	for _, action := range actions {
+		keys := config.Hotkeys[action]
+		if keys == "" {
+			// unbound
+			continue
+		}
+
+		binding := &HotkeyBinding{
+			Action: action,
+			Keys:   keys,
+		}
+		bindings = append(bindings, binding)
+
+		mods, key, err := parseHotkey(keys)
+		if err != nil {
+			binding.Error = err.Error()
+			log.Printf("Hotkey for %s: %v\n", action, err)
+			continue
+		}
+
+		combination := hotkeyCombination(mods, key)
+		if other, ok := combinations[combination]; ok {
+			binding.Error = fmt.Sprintf("Conflicts with %s (%s)", other.Action, other.Keys)
+			log.Printf("Hotkey %s for %s conflicts with %s (%s), ignoring\n", keys, action, other.Action, other.Keys)
+			continue
+		}
+		combinations[combination] = binding
+
+		hk := hotkey.New(mods, key)
+		if err := hk.Register(); err != nil {
+			binding.Error = fmt.Sprintf("Error registering hotkey: %v", err)
+			log.Printf("Hotkey %s for %s: %v\n", keys, action, binding.Error)
+			continue
+		}
+
+		binding.hotkey = hk
+		binding.Active = true
+		go binding.forward(events)
+	}
+
+	hotkeyBindings.Store(&bindings)
+	return bindings
+}
+
+// release the hotkeys so other applications can use them
+func unregisterHotkeys(bindings []*HotkeyBinding) {
+	for _, binding := range bindings {
+		if binding.Active {
+			binding.hotkey.Unregister()
+			binding.Active = false
+		}
+	}
+}
+
+// send presses and releases of the hotkey to events, ignoring auto repeat
+func (b *HotkeyBinding) forward(events chan<- hotkeyEvent) {
+	var state pushToTalk
+
+	// the channels are closed, and replaced, when the hotkey is unregistered
+	keydown := b.hotkey.Keydown()
+	keyup := b.hotkey.Keyup()
+
+	for {
+		select {
+		case _, ok := <-keydown:
+			if !ok {
+				return
+			}
+			if state.Press() {
+				events <- hotkeyEvent{Action: b.Action, Pressed: true}
+			}
+		case _, ok := <-keyup:
+			if !ok {
+				return
+			}
+			state.Release()
+		case <-state.Released():
+			state.Finish()
+			events <- hotkeyEvent{Action: b.Action, Pressed: false}
+		}
+	}
+}
+
+// with X11 key auto repeat enabled a held key sends a release immediately
+// followed by a press for every repeat, so a release only counts once no press
+// follows within this delay
+const pushToTalkReleaseDelay = 100 * time.Millisecond
+
+// tracks whether a push-to-talk hotkey is held, ignoring auto repeat
+type pushToTalk struct {
+	held         bool
+	releaseTimer *time.Timer
+}
+
+// called on key down, returns true if this starts a new press
+func (p *pushToTalk) Press() bool {
+	if p.releaseTimer != nil {
+		// auto repeat, the key is still held
+		p.releaseTimer.Stop()
+		p.releaseTimer = nil
+	}
+
+	if p.held {
+		return false
+	}
+
+	p.held = true
+	return true
+}
+
+// called on key up, the release is confirmed by Released after a delay
+func (p *pushToTalk) Release() {
+	if !p.held || p.releaseTimer != nil {
+		return
+	}
+	p.releaseTimer = time.NewTimer(pushToTalkReleaseDelay)
+}
+
+// receives when the key has been released, nil while no release is pending.
+// Finish must be called after receiving
+func (p *pushToTalk) Released() <-chan time.Time {
+	if p.releaseTimer == nil {
+		return nil
+	}
+	return p.releaseTimer.C
+}
+
+func (p *pushToTalk) Finish() {
+	p.held = false
+	p.releaseTimer = nil
+}
@@ -0,0 +1,16 @@
+package main
+
+import "golang.design/x/hotkey"
+
+// modifier names for hotkey combinations, alt and option are the same key, as
+// are super and cmd
+var hotkeyModifiers = map[string]hotkey.Modifier{
+	"ctrl":    hotkey.ModCtrl,
+	"control": hotkey.ModCtrl,
+	"shift":   hotkey.ModShift,
+	"alt":     hotkey.ModOption,
+	"option":  hotkey.ModOption,
+	"super":   hotkey.ModCmd,
+	"cmd":     hotkey.ModCmd,
+	"command": hotkey.ModCmd,
+}
@@ -0,0 +1,19 @@
+package main
+
+import "golang.design/x/hotkey"
+
+// modifier names for hotkey combinations. On X11 Alt is usually Mod1 and the
+// Super (Windows) key Mod4, but it depends on the keyboard mapping, see xmodmap
+var hotkeyModifiers = map[string]hotkey.Modifier{
+	"ctrl":    hotkey.ModCtrl,
+	"control": hotkey.ModCtrl,
+	"shift":   hotkey.ModShift,
+	"alt":     hotkey.Mod1,
+	"super":   hotkey.Mod4,
+	"win":     hotkey.Mod4,
+	"mod1":    hotkey.Mod1,
+	"mod2":    hotkey.Mod2,
+	"mod3":    hotkey.Mod3,
+	"mod4":    hotkey.Mod4,
+	"mod5":    hotkey.Mod5,
+}
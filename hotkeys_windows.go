@@ -0,0 +1,13 @@
+package main
+
+import "golang.design/x/hotkey"
+
+// modifier names for hotkey combinations
+var hotkeyModifiers = map[string]hotkey.Modifier{
+	"ctrl":    hotkey.ModCtrl,
+	"control": hotkey.ModCtrl,
+	"shift":   hotkey.ModShift,
+	"alt":     hotkey.ModAlt,
+	"super":   hotkey.ModWin,
+	"win":     hotkey.ModWin,
+}
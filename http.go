@@ -5,8 +5,14 @@ import (
 	"fmt"
 	"html/template"
 	"log"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 )
 
 var indexPageTemplate = template.Must(template.New("index").Parse(`
@@ -22,7 +28,13 @@ var indexPageTemplate = template.Must(template.New("index").Parse(`
 			<li><a href="/context">Context</a></li>
 			<li><a href="/describe-screen">Describe Screen</a></li>
 			<li><a href="/nvim">nvim Remote</a></li>
+			<li><a href="/nvim/sockets">nvim Sockets</a></li>
 			<li><a href="/history">History</a></li>
+			<li><a href="/api/history">History API</a></li>
+			<li><a href="/api/history/export?format=zip">History Export</a> (zip)</li>
+			<li><a href="/status">Status</a></li>
+			<li><a href="/hotkeys">Hotkeys</a></li>
+			<li><a href="/level">Input Level</a> (event stream)</li>
 		</ul>
 	</body>
 	</html>
@@ -37,10 +49,21 @@ var contextPageTemplate = template.Must(template.New("context").Parse(`
 	<body>
 		<h1>Current Context</h1>
 		<p>The context is sent alongside transcription to help with understanding the user's intent. It can include keywords or other relevant strings.</p>
-		<pre>{{.}}</pre>
+		{{range .}}
+			<h2>{{if .Source}}{{.Source}}{{else}}(no source){{end}}</h2>
+			<p>
+				Updated {{.UpdatedAt.Format "15:04:05"}}{{if not .ExpiresAt.IsZero}}, expires {{.ExpiresAt.Format "15:04:05"}}{{end}}
+				{{if .Scope.App}}, app: {{.Scope.App}}{{end}}{{if .Scope.Title}}, title: {{.Scope.Title}}{{end}}
+			</p>
+			<pre>{{.Text}}</pre>
+		{{else}}
+			<p>No context stored</p>
+		{{end}}
 		<form method="POST" action="/context">
 			<label for="context">Set Context:</label><br>
 			<textarea id="context" name="context" rows="4" cols="50"></textarea><br><br>
+			<label for="source">Source:</label>
+			<input id="source" name="source"><br><br>
 			<input type="submit" value="Submit">
 		</form>
 	</body>
@@ -97,26 +120,56 @@ var historyPageTemplate = template.Must(template.New("history").Parse(`
 	<body>
 		<h1>History</h1>
 		{{if .History}}
+			<p>
+				Export:
+				<a href="/api/history/export?format=jsonl">JSONL</a>,
+				<a href="/api/history/export?format=csv">CSV</a>,
+				<a href="/api/history/export?format=markdown">Markdown</a>,
+				<a href="/api/history/export?format=zip">Zip with recordings</a>
+			</p>
 			<table border="1" cellpadding="5" cellspacing="0" style="border-collapse: collapse;">
 				<tr>
 					<th>UUID</th>
+					<th>Created</th>
 					<th>Original</th>
 					<th>Modified</th>
 					<th>Repair Prompt</th>
-					<th>MP3 Recording</th>
+					<th>Recording</th>
 				</tr>
 				{{range .History}}
 					<tr>
-						<td>{{.UUID}}</td>
+						<td>
+							{{.UUID}}
+							{{if .ParentUUID}}<br><small>revision of {{.ParentUUID}}</small>{{end}}
+							{{if .Error}}
+								<br><strong style="color: #c00;">Failed to {{.FailedStage}}:</strong>
+								<pre style="white-space: pre-wrap; color: #c00;">{{.Error}}</pre>
+								<form method="POST" action="/api/history/{{.UUID}}/retry">
+									<input type="submit" value="Retry">
+								</form>
+							{{else if .AudioFormat}}
+								<form method="POST" action="/api/history/{{.UUID}}/rerun">
+									<input type="hidden" name="transcribe" value="true">
+									<input type="hidden" name="repair" value="{{if .RepairPrompt}}true{{end}}">
+									<input type="submit" value="Run again">
+								</form>
+							{{end}}
+						</td>
+						<td>{{if not .CreatedAt.IsZero}}{{.CreatedAt.Format "2006-01-02 15:04:05"}}{{end}}</td>
 						<td><pre style="white-space: pre-wrap;">{{.Original}}</pre></td>
 						<td><pre style="white-space: pre-wrap;">{{.Modified}}</pre></td>
 						<td><pre style="max-height: 200px; overflow-y: auto;">{{.RepairPrompt}}</pre></td>
 						<td>
-							{{if .Mp3Recording}}
+							{{if .AudioFormat}}
 								<audio controls preload="none">
-									<source src="/history/mp3?uuid={{.UUID}}" type="audio/mpeg">
+									<source src="/history/audio?uuid={{.UUID}}">
 								</audio>
 							{{end}}
+							{{if .Segments}}
+								<br>Subtitles:
+								<a href="/api/history/export?format=srt&uuid={{.UUID}}">SRT</a>
+								<a href="/api/history/export?format=vtt&uuid={{.UUID}}">VTT</a>
+							{{end}}
 						</td>
 					</tr>
 				{{end}}
@@ -137,6 +190,63 @@ func withCORS(handler http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
+// the history has everything that was said and can be deleted, so only pages
+// served from here and the origins in AllowedOrigins can use it. Requests
+// from any other site are refused, not just hidden from it. Also used for the
+// endpoints that decide what goes into the repair prompt and where text is
+// sent
+func withHistoryCORS(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Origin")
+
+		// a site can point its own domain at this address with DNS rebinding,
+		// then it would be the same origin
+		if !isAllowedHost(r.Host) {
+			http.Error(w, "Host not allowed", http.StatusForbidden)
+			return
+		}
+
+		origin := r.Header.Get("Origin")
+		if origin != "" && !isSameOrigin(r, origin) {
+			if !slices.Contains(config.AllowedOrigins, origin) {
+				http.Error(w, "Origin not allowed", http.StatusForbidden)
+				return
+			}
+
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		}
+
+		handler(w, r)
+	}
+}
+
+// whether the Host header names this server: localhost, a loopback address,
+// or the host in ListenAddress
+func isAllowedHost(hostport string) bool {
+	host := hostport
+	if h, _, err := net.SplitHostPort(hostport); err == nil {
+		host = h
+	}
+	host = strings.Trim(host, "[]")
+
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return true
+	}
+
+	listenHost, _, err := net.SplitHostPort(config.ListenAddress)
+	return err == nil && listenHost != "" && strings.EqualFold(host, listenHost)
+}
+
+func isSameOrigin(r *http.Request, origin string) bool {
+	originURL, err := url.Parse(origin)
+	return err == nil && (originURL.Scheme == "http" || originURL.Scheme == "https") && originURL.Host == r.Host
+}
+
 func startServer() {
 	http.HandleFunc("/", withCORS(func(w http.ResponseWriter, r *http.Request) {
 		err := indexPageTemplate.Execute(w, nil)
@@ -145,20 +255,56 @@ func startServer() {
 		}
 	}))
 
-	http.HandleFunc("/context", withCORS(func(w http.ResponseWriter, r *http.Request) {
+	// pushed context goes into the repair prompt, so it's only taken from
+	// pages served here and the allowed origins
+	http.HandleFunc("/context", withHistoryCORS(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodPost {
 			err := r.ParseForm()
 			if err != nil {
 				http.Error(w, "Error parsing form", http.StatusInternalServerError)
 				return
 			}
-			contextValue := r.FormValue("context")
-			taskManager.SetContext(contextValue)
-			fmt.Fprintf(w, "Context stored")
+
+			entry := &StoredContext{
+				Text:   r.FormValue("context"),
+				Source: r.FormValue("source"),
+				Scope: AppMatch{
+					App:   r.FormValue("app"),
+					Title: r.FormValue("title"),
+				},
+				UpdatedAt: time.Now(),
+			}
+
+			ttl := config.ContextTTLSeconds
+			if ttlValue := r.FormValue("ttl"); ttlValue != "" {
+				ttl, err = strconv.Atoi(ttlValue)
+				if err != nil || ttl < 0 {
+					http.Error(w, "Invalid ttl", http.StatusBadRequest)
+					return
+				}
+			}
+
+			if ttl > 0 {
+				entry.ExpiresAt = entry.UpdatedAt.Add(time.Duration(ttl) * time.Second)
+			}
+
+			taskManager.SetContext(entry)
+
+			if entry.Text == "" {
+				fmt.Fprintf(w, "Context cleared")
+			} else {
+				fmt.Fprintf(w, "Context stored")
+			}
 		} else if r.Method == http.MethodGet {
-			contextValue := taskManager.GetContext()
+			entries := taskManager.GetContext()
+
+			if r.URL.Query().Get("format") == "json" {
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(entries)
+				return
+			}
 
-			err := contextPageTemplate.Execute(w, contextValue)
+			err := contextPageTemplate.Execute(w, entries)
 			if err != nil {
 				http.Error(w, "Error rendering template", http.StatusInternalServerError)
 			}
@@ -208,7 +354,56 @@ func startServer() {
 		}
 	}))
 
-	http.HandleFunc("/history", withCORS(func(w http.ResponseWriter, r *http.Request) {
+	// register nvim instances that discovery can't find, eg. from an autocmd:
+	// curl -d address=$NVIM -d pid=<pid> localhost:9898/nvim/sockets
+	// registered sockets are sent dictation and read for context, so they're
+	// only taken from pages served here and the allowed origins
+	http.HandleFunc("/nvim/sockets", withHistoryCORS(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(getNvimRegistrations())
+		case http.MethodPost:
+			address := r.FormValue("address")
+			if address == "" {
+				http.Error(w, "Missing address", http.StatusBadRequest)
+				return
+			}
+
+			if r.FormValue("remove") != "" {
+				if !unregisterNvimSocket(address) {
+					http.Error(w, "Socket not registered", http.StatusNotFound)
+					return
+				}
+				fmt.Fprintf(w, "Socket removed")
+				return
+			}
+
+			// a TCP address could be anywhere, those have to be put in the
+			// config
+			if isNvimTCPAddress(address) {
+				http.Error(w, "TCP addresses can only be added with NvimSockets in the config", http.StatusBadRequest)
+				return
+			}
+
+			var pid int
+			if pidValue := r.FormValue("pid"); pidValue != "" {
+				var err error
+				pid, err = strconv.Atoi(pidValue)
+				if err != nil {
+					http.Error(w, "Invalid pid", http.StatusBadRequest)
+					return
+				}
+			}
+
+			registerNvimSocket(address, pid)
+			fmt.Fprintf(w, "Socket registered")
+		default:
+			http.Error(w, "Unsupported method", http.StatusMethodNotAllowed)
+		}
+	}))
+
+	http.HandleFunc("/history", withHistoryCORS(func(w http.ResponseWriter, r *http.Request) {
 		history := taskManager.GetHistory()
 
 		err := historyPageTemplate.Execute(w, map[string]interface{}{"History": history})
@@ -217,19 +412,79 @@ func startServer() {
 		}
 	}))
 
-	http.HandleFunc("/history/mp3", withCORS(func(w http.ResponseWriter, r *http.Request) {
+	historyAudioHandler := withHistoryCORS(func(w http.ResponseWriter, r *http.Request) {
 		uuid := r.URL.Query().Get("uuid")
 
-		history := taskManager.GetHistory()
-		for _, result := range history {
-			if result.UUID == uuid {
-				w.Header().Set("Content-Type", "audio/mpeg")
-				w.Write(result.Mp3Recording)
+		history := taskManager.History()
+		if result := history.Get(uuid); result != nil {
+			if audio, err := history.ReadAudio(result); err == nil {
+				w.Header().Set("Content-Type", audioContentType(result.AudioFormat))
+				w.Write(audio)
 				return
 			}
 		}
 
-		http.Error(w, "MP3 file not found", http.StatusNotFound)
+		http.Error(w, "Audio file not found", http.StatusNotFound)
+	})
+
+	http.HandleFunc("/history/audio", historyAudioHandler)
+	// kept for links from before other audio formats were supported
+	http.HandleFunc("/history/mp3", historyAudioHandler)
+
+	http.HandleFunc("/api/history", withHistoryCORS(historyAPIHandler))
+	http.HandleFunc("/api/history/", withHistoryCORS(historyEntryAPIHandler))
+	http.HandleFunc("/api/history/export", withHistoryCORS(historyExportAPIHandler))
+
+	http.HandleFunc("/status", withCORS(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(taskManager.GetStatus())
+	}))
+
+	http.HandleFunc("/hotkeys", withCORS(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(getHotkeyBindings())
+	}))
+
+	// server sent events with the input level while recording
+	http.HandleFunc("/level", withCORS(func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+
+		ticker := time.NewTicker(levelInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-ticker.C:
+				status := taskManager.GetStatus()
+				event := map[string]interface{}{
+					"State": status.State,
+				}
+				if status.Level != nil {
+					event["RMS"] = status.Level.RMS
+					event["Peak"] = status.Level.Peak
+					event["Decibels"] = status.Level.Decibels()
+					event["Clipped"] = status.Level.Clipped
+				}
+
+				data, err := json.Marshal(event)
+				if err != nil {
+					return
+				}
+
+				fmt.Fprintf(w, "data: %s\n\n", data)
+				flusher.Flush()
+			}
+		}
 	}))
 
 	http.HandleFunc("/start-task", withCORS(func(w http.ResponseWriter, r *http.Request) {
@@ -244,7 +499,7 @@ func startServer() {
 		// 	return
 		// }
 
-		task := taskManager.StartNewTask()
+		task := taskManager.StartNewTask(TaskOptions{})
 		<-task.waitForCompletion
 
 		result := task.GetResult()
@@ -281,3 +536,311 @@ func startServer() {
 		fmt.Fprintf(os.Stderr, "Error starting server: %v\n", err)
 	}
 }
+
+// a history entry in API responses
+type historyEntryResponse struct {
+	*TranscriptionResult
+	// where the recording can be downloaded, empty if there isn't one
+	AudioURL string `json:",omitempty"`
+}
+
+func newHistoryEntryResponse(entry *TranscriptionResult) historyEntryResponse {
+	response := historyEntryResponse{TranscriptionResult: entry}
+	if entry.AudioFormat != "" {
+		response.AudioURL = "/history/audio?uuid=" + url.QueryEscape(entry.UUID)
+	}
+	return response
+}
+
+// parse a time from a query parameter, either RFC 3339 or a date. When
+// endOfDay is set a date is the end of that day rather than the start
+func parseHistoryTime(value string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Invalid time: %s", value)
+	}
+
+	if endOfDay {
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return t, nil
+}
+
+// the history query from the parameters limit, before, q, from, to and failed
+func parseHistoryQuery(params url.Values, defaultLimit int) (HistoryQuery, error) {
+	query := HistoryQuery{
+		Limit:  defaultLimit,
+		Before: params.Get("before"),
+		Text:   params.Get("q"),
+		Failed: params.Get("failed") == "true",
+	}
+
+	if limit := params.Get("limit"); limit != "" {
+		var err error
+		query.Limit, err = strconv.Atoi(limit)
+		if err != nil || query.Limit < 0 {
+			return query, fmt.Errorf("Invalid limit")
+		}
+	}
+
+	if query.Before != "" && taskManager.History().Get(query.Before) == nil {
+		return query, fmt.Errorf("Entry for before not found")
+	}
+
+	var err error
+	if from := params.Get("from"); from != "" {
+		if query.From, err = parseHistoryTime(from, false); err != nil {
+			return query, err
+		}
+	}
+
+	if to := params.Get("to"); to != "" {
+		if query.To, err = parseHistoryTime(to, true); err != nil {
+			return query, err
+		}
+	}
+
+	return query, nil
+}
+
+// GET lists history newest first, filtered by the query parameters limit,
+// before, q, from, to and failed. DELETE removes the entries given by uuid
+// parameters, or everything with all=true
+func historyAPIHandler(w http.ResponseWriter, r *http.Request) {
+	history := taskManager.History()
+	params := r.URL.Query()
+
+	switch r.Method {
+	case http.MethodOptions:
+		w.WriteHeader(http.StatusNoContent)
+
+	case http.MethodGet:
+		query, err := parseHistoryQuery(params, 50)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		entries, more := history.Search(query)
+
+		response := struct {
+			Entries []historyEntryResponse
+			// pass as before to get the next page, empty on the last page
+			NextBefore string `json:",omitempty"`
+		}{Entries: []historyEntryResponse{}}
+
+		for _, entry := range entries {
+			response.Entries = append(response.Entries, newHistoryEntryResponse(entry))
+		}
+
+		if more {
+			response.NextBefore = entries[len(entries)-1].UUID
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+
+	case http.MethodDelete:
+		var deleted int
+
+		if params.Get("all") == "true" {
+			var err error
+			deleted, err = history.Clear()
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Error clearing history: %v", err), http.StatusInternalServerError)
+				return
+			}
+		} else {
+			uuids := params["uuid"]
+			if len(uuids) == 0 {
+				http.Error(w, "Missing uuid, or all=true to clear the history", http.StatusBadRequest)
+				return
+			}
+
+			var err error
+			deleted, err = history.Delete(uuids...)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Error deleting history: %v", err), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]int{"Deleted": deleted})
+
+	default:
+		http.Error(w, "Unsupported method", http.StatusMethodNotAllowed)
+	}
+}
+
+// download the history in the format given by the format parameter, oldest
+// first. Filtered by the same parameters as listing, without a default limit,
+// or a single entry by uuid, which subtitles need
+func historyExportAPIHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Only GET method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	params := r.URL.Query()
+	history := taskManager.History()
+
+	format, err := getHistoryExportFormat(params.Get("format"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var entries []*TranscriptionResult
+	if uuid := params.Get("uuid"); uuid != "" {
+		entry := history.Get(uuid)
+		if entry == nil {
+			http.Error(w, "Entry not found", http.StatusNotFound)
+			return
+		}
+		entries = append(entries, entry)
+	} else {
+		query, err := parseHistoryQuery(params, 0)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		entries, _ = history.Search(query)
+		slices.Reverse(entries)
+	}
+
+	// errors past this point can't change the response, so check for the
+	// ones that are known up front
+	if format.Name == "srt" || format.Name == "vtt" {
+		if len(entries) != 1 || len(entries[0].Segments) == 0 {
+			http.Error(w, "Subtitles need a uuid for an entry with segment timestamps", http.StatusBadRequest)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", format.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="talkxtyper-history-%s.%s"`, time.Now().Format("2006-01-02"), format.Extension))
+
+	if err := exportHistory(w, format, history, entries); err != nil {
+		log.Printf("Error exporting history: %v\n", err)
+	}
+}
+
+// GET or DELETE a single history entry, /api/history/<uuid>. POST to
+// /api/history/<uuid>/rerun runs it again, and /api/history/<uuid>/retry
+// retries a failed entry
+func historyEntryAPIHandler(w http.ResponseWriter, r *http.Request) {
+	history := taskManager.History()
+	uuid := strings.TrimPrefix(r.URL.Path, "/api/history/")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if strings.HasSuffix(uuid, "/rerun") {
+		historyRerunAPIHandler(w, r, strings.TrimSuffix(uuid, "/rerun"))
+		return
+	}
+
+	if strings.HasSuffix(uuid, "/retry") {
+		historyRetryAPIHandler(w, r, strings.TrimSuffix(uuid, "/retry"))
+		return
+	}
+
+	entry := history.Get(uuid)
+	if entry == nil {
+		http.Error(w, "Entry not found", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(newHistoryEntryResponse(entry))
+
+	case http.MethodDelete:
+		if _, err := history.Delete(uuid); err != nil {
+			http.Error(w, fmt.Sprintf("Error deleting history: %v", err), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "Unsupported method", http.StatusMethodNotAllowed)
+	}
+}
+
+// run a history entry again, the form values transcribe and repair choose the
+// steps, backend and prompt override the transcription backend and repair
+// prompt. Responds with the new revision
+func historyRerunAPIHandler(w http.ResponseWriter, r *http.Request, uuid string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if taskManager.History().Get(uuid) == nil {
+		http.Error(w, "Entry not found", http.StatusNotFound)
+		return
+	}
+
+	options := RerunOptions{
+		Transcribe:   r.FormValue("transcribe") == "true",
+		Backend:      r.FormValue("backend"),
+		Repair:       r.FormValue("repair") == "true",
+		RepairPrompt: r.FormValue("prompt"),
+	}
+
+	// choosing a backend or prompt implies that step
+	options.Transcribe = options.Transcribe || options.Backend != ""
+	options.Repair = options.Repair || options.RepairPrompt != ""
+
+	result, err := rerunHistoryEntry(r.Context(), uuid, options)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newHistoryEntryResponse(result))
+}
+
+// retry a failed history entry from the stage that failed. Responds with the
+// entry that replaces it
+func historyRetryAPIHandler(w http.ResponseWriter, r *http.Request, uuid string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	entry := taskManager.History().Get(uuid)
+	if entry == nil {
+		http.Error(w, "Entry not found", http.StatusNotFound)
+		return
+	}
+
+	if entry.Error == "" {
+		http.Error(w, "Entry didn't fail", http.StatusBadRequest)
+		return
+	}
+
+	result, err := retryHistoryEntry(r.Context(), uuid)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newHistoryEntryResponse(result))
+}
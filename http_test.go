@@ -0,0 +1,89 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHistoryCORS(t *testing.T) {
+	oldConfig := config
+	t.Cleanup(func() { config = oldConfig })
+	config.AllowedOrigins = []string{"chrome-extension://abcdef"}
+
+	handler := withHistoryCORS(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		method, origin string
+		status         int
+		allowOrigin    string
+	}{
+		// scripts and curl don't send an origin
+		{http.MethodGet, "", http.StatusNoContent, ""},
+		// the history page served from here
+		{http.MethodDelete, "http://localhost:9898", http.StatusNoContent, ""},
+		{http.MethodDelete, "chrome-extension://abcdef", http.StatusNoContent, "chrome-extension://abcdef"},
+		{http.MethodOptions, "chrome-extension://abcdef", http.StatusNoContent, "chrome-extension://abcdef"},
+		{http.MethodGet, "https://example.com", http.StatusForbidden, ""},
+		{http.MethodDelete, "https://example.com", http.StatusForbidden, ""},
+		{http.MethodPost, "null", http.StatusForbidden, ""},
+		{http.MethodGet, "http://localhost:9899", http.StatusForbidden, ""},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest(test.method, "http://localhost:9898/api/history", nil)
+		if test.origin != "" {
+			r.Header.Set("Origin", test.origin)
+		}
+		w := httptest.NewRecorder()
+		handler(w, r)
+
+		if w.Code != test.status {
+			t.Errorf("%s from %q: status %d, want %d", test.method, test.origin, w.Code, test.status)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != test.allowOrigin {
+			t.Errorf("%s from %q: Access-Control-Allow-Origin %q, want %q", test.method, test.origin, got, test.allowOrigin)
+		}
+	}
+}
+
+func TestHistoryCORSHost(t *testing.T) {
+	oldConfig := config
+	t.Cleanup(func() { config = oldConfig })
+	config.ListenAddress = "talkxtyper.lan:9898"
+
+	handler := withHistoryCORS(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		host   string
+		status int
+	}{
+		{"localhost:9898", http.StatusNoContent},
+		{"LOCALHOST", http.StatusNoContent},
+		{"127.0.0.1:9898", http.StatusNoContent},
+		{"[::1]:9898", http.StatusNoContent},
+		{"talkxtyper.lan:9898", http.StatusNoContent},
+		// a rebound domain is its own origin, so only the host gives it away
+		{"attacker.example:9898", http.StatusForbidden},
+		{"192.168.1.20:9898", http.StatusForbidden},
+		{"", http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest(http.MethodDelete, "http://localhost:9898/api/history?all=true", nil)
+		r.Host = test.host
+		if test.host != "" {
+			r.Header.Set("Origin", "http://"+test.host)
+		}
+		w := httptest.NewRecorder()
+		handler(w, r)
+
+		if w.Code != test.status {
+			t.Errorf("Host %q: status %d, want %d", test.host, w.Code, test.status)
+		}
+	}
+}
@@ -0,0 +1,309 @@
+// File generated by 2goarray v0.1.0 (http://github.com/cratonica/2goarray)
+
+package main
+
+var icon_yellow []byte = []byte{
+	0x89, 0x50, 0x4e, 0x47, 0x0d, 0x0a, 0x1a, 0x0a, 0x00, 0x00, 0x00, 0x0d, 
+	0x49, 0x48, 0x44, 0x52, 0x00, 0x00, 0x00, 0x40, 0x00, 0x00, 0x00, 0x40, 
+	0x08, 0x06, 0x00, 0x00, 0x00, 0xaa, 0x69, 0x71, 0xde, 0x00, 0x00, 0x0d, 
+	0xea, 0x49, 0x44, 0x41, 0x54, 0x78, 0x9c, 0xd4, 0x5b, 0x7d, 0x70, 0x14, 
+	0xc7, 0x95, 0xef, 0x99, 0xdd, 0xd9, 0xd9, 0x2f, 0x21, 0xad, 0x8c, 0x90, 
+	0x0c, 0x18, 0x84, 0x91, 0x0b, 0xc1, 0x1d, 0xa5, 0xe5, 0xa0, 0x0c, 0x3e, 
+	0x28, 0x6b, 0xf1, 0x55, 0x9d, 0x45, 0x71, 0xc7, 0x01, 0x46, 0x5c, 0xf9, 
+	0x7c, 0x87, 0x24, 0xdf, 0xd5, 0xa5, 0x1c, 0x0c, 0x5a, 0xa5, 0x12, 0x17, 
+	0xc4, 0x24, 0x5a, 0xa5, 0xec, 0x4a, 0xf9, 0x1f, 0x23, 0xc5, 0x40, 0x52, 
+	0x15, 0x63, 0x24, 0x97, 0x93, 0xb2, 0x2d, 0x3b, 0x22, 0xc1, 0x9f, 0x55, 
+	0xc4, 0x08, 0x05, 0x55, 0xa4, 0x18, 0x4a, 0x2b, 0xfe, 0x40, 0x71, 0x59, 
+	0x20, 0x61, 0x14, 0x19, 0x21, 0x83, 0x16, 0x49, 0xfb, 0x39, 0xbb, 0xdb, 
+	0xa9, 0xdf, 0x32, 0x2d, 0x66, 0x87, 0x19, 0xed, 0x2e, 0xfa, 0xb2, 0x7f, 
+	0x4f, 0x5d, 0x9a, 0xe9, 0x99, 0xe9, 0xee, 0xf7, 0xfa, 0xbd, 0xd7, 0xdd, 
+	0xaf, 0x7b, 0x8d, 0x64, 0x86, 0x41, 0x29, 0x2d, 0x24, 0x84, 0xb8, 0x08, 
+	0x21, 0x4e, 0x42, 0xc8, 0x52, 0x42, 0x08, 0xee, 0x73, 0xe4, 0xff, 0x4a, 
+	0xea, 0x27, 0x84, 0xf8, 0xe4, 0xff, 0xdd, 0x84, 0x10, 0x2f, 0x12, 0xc7, 
+	0x71, 0xfd, 0xaa, 0xf7, 0xbe, 0xfd, 0xa0, 0x94, 0xba, 0x28, 0xa5, 0xf5, 
+	0x94, 0xd2, 0x3e, 0x3a, 0x75, 0xa0, 0x8c, 0x13, 0x28, 0x93, 0x95, 0xff, 
+	0xad, 0x84, 0xcf, 0xe7, 0x73, 0x50, 0x4a, 0x6b, 0x29, 0xa5, 0x23, 0xac, 
+	0xe5, 0x33, 0x00, 0x08, 0xa3, 0x42, 0xd6, 0xaa, 0x6f, 0x07, 0x51, 0x4a, 
+	0x73, 0x28, 0xa5, 0x9e, 0x19, 0x66, 0x5c, 0x4d, 0x23, 0xb2, 0x86, 0x4d, 
+	0x59, 0x10, 0x1c, 0xbb, 0xc8, 0x14, 0x94, 0x52, 0xfc, 0x73, 0x13, 0x42, 
+	0x6a, 0x65, 0x9b, 0xd6, 0x24, 0x9f, 0xcf, 0x47, 0xbc, 0x5e, 0x2f, 0xe9, 
+	0xee, 0xee, 0x4e, 0xfc, 0x47, 0x42, 0x5e, 0x7f, 0x7f, 0xb2, 0x69, 0x17, 
+	0x16, 0x16, 0x26, 0x52, 0x4e, 0x4e, 0x0e, 0x71, 0xb9, 0x5c, 0xa4, 0xa4, 
+	0xa4, 0x24, 0xf1, 0x9f, 0x3d, 0xd7, 0x01, 0x0a, 0xa9, 0xe3, 0x38, 0xae, 
+	0x09, 0xee, 0x86, 0x65, 0xce, 0x38, 0x82, 0xc1, 0xe0, 0x32, 0x4a, 0xe9, 
+	0x19, 0xd6, 0x1d, 0x6a, 0x8c, 0x8c, 0x8c, 0xd0, 0x96, 0x96, 0x16, 0xea, 
+	0x72, 0xb9, 0x68, 0x4e, 0x4e, 0x0e, 0x95, 0x1b, 0x97, 0x71, 0xc2, 0xb7, 
+	0xdb, 0xb7, 0x6f, 0xa7, 0x8d, 0x8d, 0x8d, 0xac, 0x68, 0x3d, 0xf4, 0xc9, 
+	0xda, 0xc0, 0x4d, 0x91, 0xb5, 0xd4, 0x88, 0xc5, 0x62, 0x35, 0x7a, 0xea, 
+	0x0e, 0xc6, 0x3d, 0x1e, 0xcf, 0x94, 0x98, 0xd6, 0x4b, 0x85, 0x85, 0x85, 
+	0xb4, 0xb2, 0xb2, 0x92, 0xf6, 0xf5, 0xe9, 0xfa, 0x55, 0x98, 0x85, 0x5b, 
+	0xd6, 0xcc, 0x69, 0x27, 0x8e, 0x52, 0xca, 0xc9, 0x76, 0x37, 0xab, 0x8c, 
+	0x6b, 0x25, 0x08, 0x79, 0x12, 0x41, 0x78, 0x66, 0x42, 0x13, 0xc0, 0xbc, 
+	0xa6, 0x1e, 0x42, 0xd5, 0xd1, 0x3b, 0x5a, 0x0d, 0x9d, 0xc9, 0x84, 0x3a, 
+	0xf5, 0x4c, 0x23, 0x16, 0x8b, 0x35, 0x4e, 0x8b, 0x10, 0xa0, 0x4e, 0x72, 
+	0xcf, 0x7b, 0x59, 0xe1, 0x0c, 0x50, 0x77, 0xb7, 0xdb, 0xad, 0xd9, 0xb8, 
+	0xd9, 0x4c, 0x68, 0x03, 0xda, 0xa2, 0x46, 0x3c, 0x1e, 0xf7, 0x4e, 0x49, 
+	0x08, 0x60, 0x9c, 0x10, 0xc2, 0x6b, 0xf5, 0x3c, 0xd4, 0xcf, 0xe9, 0x74, 
+	0x6a, 0x36, 0x68, 0x2e, 0x12, 0xb4, 0x41, 0xcb, 0x24, 0xa6, 0xaa, 0x09, 
+	0x5c, 0x2c, 0x16, 0xab, 0x63, 0x85, 0x31, 0xa0, 0xa2, 0xb9, 0x50, 0xf9, 
+	0x29, 0x08, 0xa1, 0x01, 0x1d, 0xc9, 0x98, 0x4a, 0x07, 0x9c, 0xc7, 0xe3, 
+	0xe1, 0x65, 0x6f, 0xff, 0x9d, 0x60, 0x3e, 0x0d, 0x21, 0xd4, 0xe8, 0x09, 
+	0x41, 0x4b, 0x3d, 0xf8, 0xa1, 0xa1, 0xa1, 0x65, 0x0b, 0x16, 0x2c, 0x38, 
+	0xaf, 0x9c, 0xe0, 0x60, 0xe2, 0xb2, 0x79, 0xf3, 0xe6, 0x7b, 0x26, 0x30, 
+	0xe9, 0x92, 0x60, 0xcd, 0x21, 0xdf, 0x2f, 0xe3, 0x88, 0xb3, 0x48, 0x24, 
+	0xce, 0x22, 0x33, 0x29, 0x2c, 0x10, 0x48, 0x8e, 0xdd, 0x80, 0x47, 0xc4, 
+	0x37, 0x1e, 0x23, 0xfd, 0xd7, 0xa5, 0x44, 0x3a, 0x79, 0x6e, 0x8c, 0x34, 
+	0x5f, 0xc8, 0x25, 0x81, 0x9b, 0xf7, 0x57, 0x0f, 0x26, 0x53, 0x67, 0xce, 
+	0x9c, 0x49, 0x4c, 0xaa, 0x58, 0x1e, 0xaa, 0xb8, 0x71, 0xe3, 0xc6, 0xba, 
+	0xfc, 0xfc, 0xfc, 0x2b, 0xb2, 0xb0, 0x74, 0x05, 0xc0, 0xc9, 0x1e, 0xff, 
+	0xb2, 0x72, 0xb5, 0x86, 0x99, 0xdb, 0x9a, 0x35, 0x6b, 0xee, 0x8b, 0x79, 
+	0x57, 0x89, 0x95, 0xd4, 0x56, 0x3c, 0x40, 0x5c, 0x4e, 0x1b, 0xcb, 0x4a, 
+	0x0b, 0x10, 0x44, 0xc3, 0xfb, 0x23, 0xa4, 0xb5, 0x3b, 0xc0, 0xb2, 0xd2, 
+	0x86, 0xd3, 0xe9, 0x4c, 0x08, 0x01, 0xb3, 0x4a, 0x06, 0x4a, 0xe9, 0x59, 
+	0x9e, 0xe7, 0xff, 0x85, 0x52, 0x1a, 0xe3, 0xb8, 0xbb, 0x6c, 0xf3, 0xaa, 
+	0xa9, 0x2d, 0x17, 0x0c, 0x06, 0xab, 0xd4, 0x4b, 0xd5, 0xba, 0xba, 0xba, 
+	0x8c, 0x99, 0xb7, 0x3e, 0x50, 0x48, 0xce, 0xbc, 0xfa, 0x10, 0x39, 0x73, 
+	0x78, 0x49, 0xc6, 0xcc, 0xe3, 0x6f, 0xfb, 0xa6, 0xac, 0xc4, 0xb7, 0x2d, 
+	0x3f, 0x5b, 0x94, 0x28, 0x2b, 0x13, 0x60, 0xba, 0x8d, 0x36, 0x2b, 0xc1, 
+	0x71, 0x5c, 0xa9, 0x24, 0x49, 0xd5, 0x1c, 0xc7, 0xf1, 0x9a, 0x1a, 0x00, 
+	0xaf, 0x3f, 0x30, 0x30, 0xb0, 0x7c, 0xd1, 0xa2, 0x45, 0xa7, 0x39, 0x8e, 
+	0x5b, 0xca, 0xf2, 0x1b, 0x1b, 0x1b, 0x49, 0x55, 0x55, 0x15, 0xbb, 0x4d, 
+	0x8b, 0xaa, 0x77, 0x3a, 0x88, 0xa7, 0x72, 0xfe, 0x84, 0x8a, 0x4f, 0x95, 
+	0x60, 0x22, 0x75, 0x4d, 0xdf, 0x90, 0xfa, 0xf7, 0x47, 0x58, 0x56, 0x5a, 
+	0x68, 0x69, 0x69, 0x21, 0xdb, 0xb7, 0x6f, 0x67, 0xb7, 0x20, 0xdf, 0x85, 
+	0x0b, 0x17, 0x1e, 0x59, 0xb7, 0x6e, 0xdd, 0x4d, 0x66, 0x0a, 0x3c, 0x63, 
+	0x7e, 0xf7, 0xee, 0xdd, 0x7c, 0x41, 0x41, 0xc1, 0x7e, 0x25, 0xf3, 0xe8, 
+	0x75, 0xb5, 0x24, 0x53, 0xa1, 0x76, 0xcf, 0x03, 0xa4, 0xfe, 0xf9, 0xfc, 
+	0x69, 0x63, 0x1e, 0x7f, 0x28, 0xeb, 0xf0, 0xde, 0xfc, 0x44, 0xd9, 0x99, 
+	0xa0, 0xa6, 0xa6, 0x26, 0x61, 0xbe, 0x0a, 0xe4, 0xac, 0x5e, 0xbd, 0xba, 
+	0xba, 0xbc, 0xbc, 0x9c, 0x67, 0x53, 0x66, 0x03, 0x2e, 0xa0, 0x16, 0xa7, 
+	0x4e, 0x9d, 0x5a, 0x9e, 0x9b, 0x9b, 0x7b, 0x94, 0xe3, 0x38, 0x33, 0x7b, 
+	0xbb, 0xa1, 0xa1, 0x81, 0x9c, 0x3c, 0x79, 0x92, 0xdd, 0xa6, 0x04, 0x1a, 
+	0xe8, 0xa9, 0xcc, 0x63, 0xb7, 0xd3, 0x8e, 0x84, 0x29, 0x51, 0x4a, 0xce, 
+	0x76, 0x07, 0x59, 0xd6, 0xa4, 0x00, 0xf3, 0x16, 0x8b, 0x25, 0x69, 0x55, 
+	0xc9, 0xf3, 0x7c, 0xc9, 0x83, 0x0f, 0x3e, 0xf8, 0x7a, 0x63, 0x63, 0x63, 
+	0xa8, 0xb5, 0xb5, 0x35, 0x31, 0xcb, 0xe3, 0x20, 0x00, 0xbf, 0xdf, 0x5f, 
+	0x65, 0xb5, 0x5a, 0x7f, 0xcd, 0x5e, 0x44, 0xef, 0x2f, 0x5b, 0xb6, 0x8c, 
+	0xdd, 0xa6, 0x04, 0xd4, 0x1e, 0x3d, 0x3f, 0x1b, 0xa8, 0x39, 0x3a, 0x94, 
+	0xb6, 0x39, 0xc0, 0x11, 0xf6, 0xf5, 0xf5, 0x25, 0x39, 0xc4, 0x48, 0x24, 
+	0xf2, 0x92, 0x28, 0x8a, 0x98, 0xe3, 0xc4, 0x78, 0x70, 0x0f, 0x4d, 0xb0, 
+	0x58, 0x2c, 0x87, 0xd8, 0x0b, 0x20, 0x8f, 0xc7, 0xc3, 0x2e, 0x53, 0x12, 
+	0x9c, 0x14, 0x6c, 0x7e, 0xb6, 0x50, 0x5b, 0x31, 0x3f, 0x6d, 0xc7, 0x08, 
+	0x2d, 0x80, 0x26, 0x2b, 0x21, 0x08, 0xc2, 0x3e, 0xf0, 0xcc, 0xc9, 0x1e, 
+	0x91, 0x1f, 0x1c, 0x1c, 0xdc, 0xac, 0xb4, 0x7d, 0xd0, 0xd9, 0xb3, 0x67, 
+	0xd9, 0x65, 0x4a, 0xfc, 0xa6, 0x5a, 0x9a, 0x56, 0x9b, 0x4f, 0x45, 0xa8, 
+	0xeb, 0xc3, 0x17, 0x63, 0xec, 0x36, 0x25, 0xea, 0xeb, 0xeb, 0x93, 0x7c, 
+	0x01, 0xc7, 0x71, 0xd9, 0x83, 0x83, 0x83, 0x08, 0xd4, 0xf2, 0x09, 0x01, 
+	0xcc, 0x9f, 0x3f, 0xff, 0xbf, 0xd9, 0x43, 0xe6, 0xf9, 0xd3, 0x1d, 0xf6, 
+	0x30, 0xce, 0x63, 0xc8, 0x9a, 0x6d, 0xc0, 0x1f, 0xa0, 0xee, 0x74, 0x00, 
+	0xe6, 0x5b, 0x5b, 0x5b, 0xd9, 0x6d, 0x82, 0x72, 0x73, 0x73, 0xff, 0x9d, 
+	0x09, 0xc0, 0x60, 0x30, 0x18, 0x1e, 0x67, 0x0f, 0x40, 0x99, 0x38, 0xbe, 
+	0xea, 0xa7, 0x1c, 0xec, 0x72, 0xd6, 0x81, 0x09, 0x56, 0xba, 0xd0, 0x30, 
+	0x03, 0x08, 0xc0, 0xc0, 0xb7, 0xb5, 0xb5, 0x2d, 0xe3, 0x79, 0x7e, 0xc9, 
+	0xfd, 0xa8, 0x3f, 0xec, 0x70, 0x2e, 0x7a, 0x9f, 0x01, 0x5a, 0x80, 0x29, 
+	0x76, 0x3a, 0x60, 0xb1, 0x48, 0x06, 0xf0, 0x9c, 0xe0, 0x7d, 0xf9, 0xf2, 
+	0xe5, 0x25, 0x2c, 0x13, 0x04, 0x55, 0x51, 0xbe, 0x38, 0x19, 0x76, 0xaf, 
+	0xcb, 0x6c, 0x62, 0x32, 0x13, 0xd8, 0xbb, 0x85, 0x53, 0x67, 0x4d, 0x1a, 
+	0x9c, 0x65, 0xf7, 0xa0, 0x95, 0x2b, 0x57, 0x3e, 0xce, 0xdb, 0x6c, 0xb6, 
+	0x24, 0x01, 0x20, 0x7a, 0x9b, 0x2e, 0xfe, 0x63, 0xa3, 0x9d, 0x5d, 0xce, 
+	0x19, 0x4a, 0x96, 0x8b, 0xec, 0x32, 0x25, 0xd4, 0xbc, 0x99, 0xcd, 0xe6, 
+	0x12, 0x5e, 0x14, 0xc5, 0xd5, 0x2c, 0x03, 0x84, 0x45, 0x44, 0xba, 0xc0, 
+	0x8a, 0x6e, 0xae, 0x81, 0x95, 0x65, 0xba, 0x50, 0x6b, 0x80, 0x20, 0x08, 
+	0x4b, 0x78, 0x83, 0xc1, 0x90, 0x64, 0xff, 0xb7, 0x6f, 0xdf, 0x66, 0x97, 
+	0xdf, 0x09, 0x01, 0x64, 0xd2, 0x06, 0xb5, 0x00, 0xe0, 0x07, 0x78, 0x9e, 
+	0xe7, 0xb3, 0x59, 0x06, 0x9b, 0x01, 0xa6, 0x8b, 0xd9, 0x1c, 0xfb, 0xa7, 
+	0xa3, 0x0d, 0x6a, 0xdf, 0x06, 0xde, 0x39, 0xaa, 0x0a, 0xa4, 0x2b, 0xd7, 
+	0xca, 0xa9, 0x40, 0x3f, 0x2b, 0x66, 0x97, 0x73, 0x0a, 0xee, 0x89, 0xbf, 
+	0xb2, 0xcb, 0x94, 0x50, 0xb1, 0xab, 0x1d, 0x26, 0x4a, 0x17, 0x58, 0xa6, 
+	0xce, 0x35, 0xa6, 0xda, 0x86, 0x29, 0x09, 0x00, 0x21, 0xac, 0xb9, 0xc6, 
+	0x54, 0xdb, 0x30, 0xe9, 0x01, 0x89, 0x91, 0x3f, 0x3c, 0xa2, 0x69, 0x63, 
+	0x6c, 0x35, 0xd6, 0x7d, 0x39, 0x9c, 0x91, 0x17, 0x9e, 0x09, 0xba, 0x3a, 
+	0x74, 0x47, 0x00, 0xee, 0xa7, 0x1c, 0x89, 0x98, 0x81, 0x1a, 0xd0, 0x10, 
+	0xc7, 0xb6, 0x2f, 0xd9, 0xed, 0x3d, 0xe0, 0x25, 0x49, 0x1a, 0x50, 0x07, 
+	0x15, 0x19, 0xbc, 0xbd, 0x21, 0x76, 0xa9, 0x39, 0xf6, 0xea, 0x3d, 0x9f, 
+	0x4d, 0xb4, 0xfc, 0x69, 0x0c, 0xff, 0x48, 0xa9, 0xce, 0xba, 0x40, 0xd9, 
+	0x46, 0x55, 0xa0, 0x94, 0x80, 0x77, 0x3e, 0x1e, 0x8f, 0x8f, 0xaa, 0xd7, 
+	0xcf, 0x0c, 0x7a, 0x81, 0x07, 0x97, 0xf3, 0x4e, 0x65, 0x47, 0x3f, 0xa2, 
+	0x5a, 0x8f, 0x67, 0x95, 0x10, 0x41, 0xc6, 0x05, 0xa2, 0xcd, 0x5a, 0x50, 
+	0xf2, 0xa0, 0x16, 0x40, 0x2c, 0x16, 0x1b, 0xe3, 0x83, 0xc1, 0xe0, 0xa0, 
+	0x3a, 0xa2, 0xca, 0xd0, 0xea, 0xd5, 0x8e, 0xc8, 0x16, 0x16, 0x98, 0x12, 
+	0x2b, 0x31, 0x29, 0xe8, 0x23, 0xad, 0x5e, 0x3f, 0xcb, 0x9e, 0x75, 0xfc, 
+	0xbe, 0x7d, 0x2c, 0x11, 0x3e, 0xcf, 0x7e, 0xc8, 0x99, 0x68, 0x93, 0x16, 
+	0x94, 0x3c, 0x28, 0x3b, 0x57, 0x0e, 0x8c, 0x0c, 0xf0, 0xa1, 0x50, 0x68, 
+	0x40, 0x57, 0x00, 0xdd, 0x01, 0x5d, 0x2f, 0xcb, 0xb4, 0xa0, 0xae, 0xe9, 
+	0xe6, 0x9c, 0x69, 0x41, 0xfd, 0x7b, 0x77, 0xd6, 0x22, 0x0d, 0xff, 0x35, 
+	0xc4, 0xb2, 0x92, 0xd0, 0x7f, 0x3d, 0x92, 0x14, 0x56, 0x57, 0x1f, 0xb8, 
+	0xf0, 0xf9, 0x7c, 0x3d, 0xfc, 0x8d, 0x1b, 0x37, 0x2e, 0xb1, 0x0c, 0x10, 
+	0x4e, 0x66, 0x28, 0xd1, 0xf4, 0xa9, 0xf6, 0xcc, 0x10, 0xcb, 0x60, 0xac, 
+	0xc4, 0x50, 0xc1, 0x5c, 0x68, 0x01, 0xf6, 0x0d, 0x50, 0x37, 0x56, 0xa4, 
+	0xa5, 0x25, 0x16, 0x96, 0xad, 0xdb, 0xfb, 0x5a, 0xbc, 0x5d, 0xbb, 0x76, 
+	0xad, 0x87, 0xff, 0xf8, 0xe3, 0x8f, 0x3b, 0xd5, 0x1a, 0xa0, 0x54, 0x95, 
+	0x93, 0xe7, 0xc6, 0xd9, 0x65, 0x12, 0x30, 0x3a, 0xbc, 0x58, 0x7e, 0x67, 
+	0x10, 0xd9, 0xfa, 0xb2, 0x61, 0xca, 0xe3, 0x71, 0x26, 0x84, 0xba, 0x9e, 
+	0x69, 0xb8, 0x33, 0x05, 0xfe, 0xd1, 0xd6, 0x31, 0x5d, 0xf5, 0xdf, 0x7b, 
+	0xfc, 0xee, 0x08, 0xc5, 0x8e, 0xde, 0x28, 0xd1, 0xda, 0xda, 0x7a, 0x89, 
+	0xaf, 0xad, 0xad, 0x1d, 0x88, 0x44, 0x22, 0x7f, 0x63, 0x99, 0x78, 0x51, 
+	0x6d, 0x06, 0x7a, 0x3d, 0x0c, 0x2d, 0x40, 0x0f, 0xc0, 0x0e, 0x11, 0xb7, 
+	0x9f, 0x2d, 0xa0, 0x2e, 0xd4, 0x89, 0xba, 0xdd, 0xbb, 0x72, 0x55, 0x4f, 
+	0xe5, 0x76, 0x7b, 0xfd, 0x49, 0xdb, 0x6b, 0x6a, 0xe6, 0xc3, 0xe1, 0xf0, 
+	0xe0, 0xa1, 0x43, 0x87, 0x06, 0x10, 0x14, 0x8d, 0x0f, 0x0f, 0x0f, 0xff, 
+	0x91, 0x3d, 0x00, 0x55, 0x57, 0x57, 0xab, 0x2a, 0xbc, 0x99, 0x32, 0x36, 
+	0x87, 0x79, 0xc1, 0x6c, 0x08, 0x41, 0xb9, 0x41, 0x32, 0x59, 0x2c, 0x12, 
+	0x5a, 0xa9, 0x84, 0x6a, 0x83, 0x84, 0xdc, 0xbe, 0x7d, 0xbb, 0xd3, 0x6c, 
+	0x36, 0xc7, 0xe0, 0x04, 0xe3, 0x1d, 0x1d, 0x1d, 0x9f, 0xb1, 0x07, 0x4c, 
+	0x5a, 0x4a, 0x33, 0x80, 0x16, 0xc0, 0xe6, 0xb4, 0x80, 0xa8, 0x0c, 0xdb, 
+	0xb0, 0xf0, 0x34, 0x7d, 0x33, 0xa3, 0x42, 0x40, 0xd9, 0x1e, 0xb9, 0x7c, 
+	0xd4, 0xa9, 0x17, 0x8d, 0x6a, 0xfc, 0xc4, 0x97, 0xd4, 0xfb, 0x18, 0xfe, 
+	0x2a, 0x2a, 0x2a, 0xd8, 0x6d, 0x82, 0xda, 0xdb, 0xdb, 0x4f, 0xa2, 0xf3, 
+	0x79, 0xab, 0xd5, 0x2a, 0xed, 0xda, 0xb5, 0xab, 0x03, 0x63, 0x22, 0x7b, 
+	0x08, 0xe6, 0xdd, 0x6e, 0x77, 0x82, 0x79, 0x86, 0xdd, 0x3f, 0xf7, 0xeb, 
+	0xda, 0x39, 0x36, 0x43, 0x2a, 0xfe, 0x75, 0xde, 0x84, 0x10, 0x6a, 0x8e, 
+	0x0e, 0x4d, 0xab, 0x4f, 0x40, 0x59, 0x28, 0x93, 0x31, 0x8f, 0xba, 0xf4, 
+	0x36, 0x60, 0xe0, 0xf9, 0x95, 0xb6, 0xaf, 0xa7, 0xfe, 0x3b, 0x77, 0xee, 
+	0xec, 0xe4, 0x38, 0x2e, 0xc6, 0x07, 0x02, 0x81, 0x38, 0x21, 0x24, 0x76, 
+	0xe5, 0xca, 0x95, 0xb7, 0xd4, 0x66, 0xa0, 0xd4, 0x02, 0x8c, 0xf9, 0x35, 
+	0x47, 0x6f, 0xb0, 0xdb, 0x7b, 0xd0, 0x78, 0x60, 0x21, 0x71, 0xcb, 0x01, 
+	0x52, 0xa8, 0xe8, 0xa2, 0x2a, 0x49, 0x57, 0x6b, 0x32, 0x21, 0xf8, 0x1f, 
+	0x94, 0xc5, 0xd4, 0x1e, 0xcc, 0xa3, 0x2e, 0x3d, 0x78, 0x1a, 0xbf, 0x49, 
+	0xea, 0x7d, 0x50, 0x6d, 0x6d, 0x2d, 0xbb, 0x4c, 0xfc, 0x7d, 0xfe, 0xf9, 
+	0xe7, 0xbf, 0x14, 0x45, 0x31, 0x16, 0x08, 0x04, 0x62, 0x30, 0x14, 0xb3, 
+	0xd9, 0x6c, 0x36, 0xf6, 0xf4, 0xf4, 0xf4, 0x3d, 0xfd, 0xf4, 0xd3, 0xbb, 
+	0x79, 0x9e, 0x17, 0xe5, 0x70, 0x11, 0x09, 0x87, 0xc3, 0x49, 0xe1, 0x64, 
+	0xef, 0xe5, 0x30, 0x71, 0xd8, 0x79, 0xb2, 0x61, 0x95, 0xf6, 0xb0, 0x53, 
+	0xf6, 0xa8, 0x7d, 0x62, 0xeb, 0x0a, 0x02, 0x7b, 0xe7, 0xcc, 0x18, 0x39, 
+	0xeb, 0x0d, 0x90, 0x1c, 0x3b, 0x4f, 0x8a, 0x97, 0x88, 0x19, 0x33, 0x5e, 
+	0xf5, 0xca, 0x75, 0x52, 0xf7, 0xe6, 0xcd, 0x44, 0x59, 0x4c, 0xed, 0xeb, 
+	0x9f, 0x2f, 0x60, 0xaf, 0xdc, 0x03, 0x98, 0x48, 0xc3, 0xef, 0x92, 0xe3, 
+	0x94, 0x60, 0x5e, 0x69, 0xff, 0xd0, 0xf4, 0x63, 0xc7, 0x8e, 0x1d, 0x6e, 
+	0x6b, 0x6b, 0x1b, 0xce, 0xca, 0xca, 0x0a, 0x61, 0xf1, 0x9f, 0x63, 0xb1, 
+	0x58, 0x6c, 0xc1, 0x60, 0x70, 0x5e, 0x4f, 0x4f, 0x4f, 0x75, 0x71, 0x71, 
+	0xf1, 0xf7, 0x52, 0x9d, 0x0b, 0xc0, 0xb6, 0xf7, 0x64, 0x5b, 0xde, 0x98, 
+	0x7f, 0x6f, 0x3c, 0x48, 0x92, 0x7a, 0x02, 0x1e, 0x1b, 0x41, 0x54, 0xc4, 
+	0x11, 0x11, 0xc5, 0xd1, 0x3a, 0x20, 0x81, 0xef, 0xbc, 0xbd, 0x61, 0x72, 
+	0xec, 0x13, 0x4a, 0xa4, 0xc0, 0xdd, 0xe0, 0x05, 0xbe, 0x85, 0xb3, 0x9d, 
+	0xac, 0x4e, 0x68, 0xdb, 0x8e, 0x9f, 0x4e, 0x0c, 0x66, 0xba, 0x87, 0x25, 
+	0xfa, 0xfb, 0xfb, 0x7f, 0xbb, 0x62, 0xc5, 0x8a, 0x97, 0x22, 0x91, 0x08, 
+	0x9c, 0xda, 0x18, 0x04, 0x60, 0xb7, 0xd9, 0x6c, 0x36, 0xbf, 0xdf, 0x9f, 
+	0x55, 0x56, 0x56, 0x56, 0xf0, 0xc1, 0x07, 0x1f, 0x7c, 0x64, 0x30, 0x18, 
+	0x26, 0xbc, 0x0b, 0xf6, 0x08, 0x76, 0xec, 0xd8, 0xc1, 0x6e, 0x13, 0x24, 
+	0x58, 0x72, 0xc8, 0x5f, 0x1a, 0x72, 0x52, 0xae, 0x04, 0xe1, 0x8c, 0x60, 
+	0x8f, 0x6a, 0x95, 0x4c, 0x17, 0x98, 0x68, 0xfd, 0x78, 0x97, 0x21, 0xe5, 
+	0x86, 0x2b, 0x04, 0xf7, 0xa8, 0xdb, 0x97, 0x24, 0x34, 0xb6, 0xc1, 0xa3, 
+	0x74, 0x7e, 0xa1, 0x50, 0xe8, 0xeb, 0x83, 0x07, 0x0f, 0xfe, 0x6f, 0x7d, 
+	0x7d, 0x3d, 0x96, 0x87, 0x10, 0x80, 0x1f, 0x5d, 0x60, 0x90, 0x24, 0x09, 
+	0x71, 0x01, 0x43, 0x6f, 0x6f, 0x6f, 0x7c, 0xdb, 0xb6, 0x6d, 0xf1, 0x85, 
+	0x0b, 0x17, 0xfe, 0x33, 0xfb, 0xa8, 0xb8, 0xb8, 0x18, 0x43, 0x06, 0xe9, 
+	0xe8, 0xe8, 0x60, 0x59, 0x24, 0x1e, 0x0d, 0x91, 0x37, 0x4e, 0x13, 0xb2, 
+	0x7a, 0x29, 0x9d, 0x54, 0xb5, 0x21, 0xa0, 0x17, 0x77, 0x1b, 0x89, 0x73, 
+	0xb9, 0x48, 0xc2, 0x11, 0x4a, 0x2e, 0xdf, 0xb4, 0x92, 0xb8, 0x34, 0xf9, 
+	0x0a, 0x12, 0x4c, 0xef, 0x79, 0x42, 0x24, 0x87, 0xf7, 0x2e, 0x20, 0xc7, 
+	0x7f, 0x90, 0x3b, 0x69, 0xaf, 0x33, 0x53, 0x79, 0xfc, 0x85, 0xd1, 0x7b, 
+	0x98, 0x87, 0x0f, 0x3b, 0x70, 0xe0, 0x00, 0xbb, 0x4d, 0x50, 0x5b, 0x5b, 
+	0xdb, 0xab, 0x6e, 0xb7, 0xfb, 0xcf, 0xb1, 0x58, 0x2c, 0x04, 0xf5, 0x8f, 
+	0x44, 0x22, 0x11, 0x68, 0x80, 0xe0, 0x70, 0x38, 0xac, 0xe1, 0x70, 0xd8, 
+	0x16, 0x08, 0x04, 0xa0, 0x63, 0xf6, 0x5b, 0xb7, 0x6e, 0xbd, 0xee, 0x70, 
+	0x38, 0xfe, 0x89, 0x7d, 0x08, 0x53, 0xc0, 0xf9, 0x20, 0x75, 0x50, 0x11, 
+	0xe4, 0xa9, 0x98, 0x9f, 0xd8, 0xac, 0x4c, 0x17, 0xe8, 0x2d, 0xa8, 0x3b, 
+	0xd6, 0xf1, 0xbe, 0xf1, 0xb8, 0x3c, 0x9f, 0xe0, 0x49, 0xb6, 0x8d, 0x4f, 
+	0xac, 0x2f, 0xf4, 0x66, 0x75, 0x5a, 0x68, 0x78, 0xff, 0x16, 0x71, 0x6b, 
+	0x38, 0x66, 0x0c, 0x7b, 0x5d, 0x5d, 0x5d, 0x49, 0x4e, 0x1c, 0xbd, 0x6f, 
+	0xb1, 0x58, 0xb6, 0xa2, 0xd7, 0xad, 0x56, 0xeb, 0x58, 0x20, 0x10, 0x08, 
+	0xac, 0x5a, 0xb5, 0x2a, 0xc8, 0xc9, 0x41, 0x11, 0xd4, 0x6a, 0x35, 0x9b, 
+	0xcd, 0xb6, 0x50, 0x28, 0x94, 0xf5, 0xdc, 0x73, 0xcf, 0x3d, 0xfc, 0xda, 
+	0x6b, 0xaf, 0xbd, 0xa5, 0x34, 0x85, 0xc9, 0x0e, 0x49, 0x55, 0x3e, 0x99, 
+	0x9d, 0x38, 0x07, 0x94, 0x49, 0xe3, 0xa7, 0x02, 0xf8, 0x8c, 0xaa, 0x57, 
+	0xbe, 0x26, 0x27, 0xdb, 0xc7, 0xd3, 0x3a, 0x24, 0x15, 0x8d, 0x46, 0xc7, 
+	0xf7, 0xef, 0xdf, 0xff, 0xcc, 0xf1, 0xe3, 0xc7, 0xaf, 0x44, 0x22, 0x11, 
+	0x7c, 0x84, 0x84, 0x65, 0x62, 0x42, 0x03, 0xf8, 0xa2, 0xa2, 0x22, 0xa1, 
+	0xb7, 0xb7, 0x17, 0x6e, 0x1a, 0x1a, 0x00, 0xfb, 0xb7, 0x76, 0x74, 0x74, 
+	0x54, 0xac, 0x5f, 0xbf, 0x3e, 0x69, 0x32, 0x30, 0x99, 0x10, 0xe0, 0xa8, 
+	0x8e, 0xfd, 0x5f, 0x98, 0x54, 0x3c, 0x99, 0x14, 0x64, 0x9e, 0x76, 0x82, 
+	0x5f, 0xf9, 0xff, 0x23, 0xa1, 0x7b, 0x54, 0x5e, 0x8f, 0x79, 0x50, 0x67, 
+	0x67, 0x67, 0xfd, 0x86, 0x0d, 0x1b, 0x70, 0xa4, 0x3e, 0xc0, 0x7a, 0x1f, 
+	0x4a, 0x41, 0x08, 0x91, 0x38, 0xf9, 0x9c, 0x10, 0xbf, 0x78, 0xf1, 0x62, 
+	0xd3, 0xc0, 0xc0, 0x80, 0x55, 0x16, 0x82, 0x5d, 0x10, 0x04, 0xeb, 0xa5, 
+	0x4b, 0x97, 0x7e, 0x58, 0x54, 0x54, 0xf4, 0x9f, 0xe9, 0x0a, 0x61, 0x26, 
+	0x05, 0x01, 0x5b, 0xc7, 0xd2, 0x5b, 0xef, 0xd4, 0x98, 0x1e, 0xf3, 0x17, 
+	0x2f, 0x5e, 0x3c, 0x5e, 0x52, 0x52, 0xf2, 0x2b, 0xb9, 0xc7, 0xfd, 0x59, 
+	0x59, 0x59, 0xe3, 0x63, 0x63, 0x63, 0xc1, 0xb5, 0x6b, 0xd7, 0x4a, 0xe7, 
+	0xcf, 0x9f, 0x8f, 0xb2, 0x18, 0x38, 0xaf, 0x34, 0x05, 0x51, 0x14, 0xed, 
+	0x94, 0x52, 0x7b, 0x24, 0x12, 0xb1, 0x5c, 0xbb, 0x76, 0xed, 0x27, 0x8b, 
+	0x17, 0x2f, 0xde, 0x9a, 0x89, 0x10, 0x98, 0x20, 0x5e, 0xf8, 0xb7, 0x71, 
+	0x52, 0xf1, 0xe4, 0xbc, 0x8c, 0xec, 0x5a, 0x49, 0x98, 0xd5, 0x35, 0x7d, 
+	0x3a, 0x4a, 0x5e, 0x6e, 0x8e, 0x6a, 0xf6, 0x38, 0x23, 0xa7, 0xd3, 0x99, 
+	0x38, 0x10, 0xa5, 0x66, 0xfe, 0xab, 0xaf, 0xbe, 0xfa, 0x68, 0xe9, 0xd2, 
+	0xa5, 0x75, 0x26, 0x93, 0x29, 0xc4, 0x71, 0x9c, 0x3f, 0x1c, 0x0e, 0x33, 
+	0xd5, 0x0f, 0x13, 0x42, 0xa2, 0xf0, 0xe7, 0x4c, 0x00, 0x9c, 0xcb, 0xe5, 
+	0x32, 0xb4, 0xb6, 0xb6, 0x42, 0x08, 0x66, 0xcc, 0x0b, 0xe2, 0xf1, 0xb8, 
+	0x0d, 0x8e, 0x91, 0x10, 0x22, 0x8e, 0x8e, 0x8e, 0xbe, 0x99, 0x95, 0x95, 
+	0xf5, 0x88, 0xfc, 0xee, 0x84, 0x63, 0xc4, 0x01, 0x2a, 0x1c, 0x3e, 0x48, 
+	0x05, 0x36, 0x07, 0x40, 0xd8, 0x0a, 0x7b, 0x79, 0x98, 0x03, 0x28, 0x85, 
+	0x02, 0x9b, 0x66, 0x73, 0x01, 0x04, 0x5a, 0x31, 0x17, 0x78, 0xf7, 0xbc, 
+	0x23, 0xad, 0xe1, 0x13, 0xde, 0x1e, 0xa7, 0x59, 0x94, 0x0e, 0x0f, 0x34, 
+	0x3a, 0x3a, 0xda, 0x9b, 0x9d, 0x9d, 0xfd, 0x3f, 0x82, 0x20, 0x84, 0x25, 
+	0x49, 0xf2, 0x2b, 0xec, 0x1e, 0xc3, 0x10, 0x22, 0xa9, 0x71, 0xf5, 0xa1, 
+	0x49, 0xa6, 0x05, 0xd6, 0xac, 0xac, 0x2c, 0xac, 0x6e, 0x96, 0x98, 0x4c, 
+	0xa6, 0x95, 0x84, 0x10, 0x8c, 0x06, 0x8f, 0x5d, 0xbd, 0x7a, 0xf5, 0x43, 
+	0x76, 0xf4, 0x54, 0x89, 0x13, 0x27, 0x4e, 0xcc, 0xc9, 0xf1, 0x59, 0xd4, 
+	0x89, 0xa3, 0xfa, 0x5a, 0x40, 0x5b, 0xd1, 0x66, 0xb4, 0x1d, 0x3c, 0x58, 
+	0x2c, 0x96, 0x87, 0x64, 0x9e, 0xac, 0x32, 0x8f, 0xac, 0xe3, 0xef, 0x02, 
+	0x87, 0xa5, 0x70, 0x46, 0x78, 0xed, 0xda, 0xb5, 0x88, 0x34, 0xd8, 0xed, 
+	0x76, 0x7b, 0x9e, 0xd9, 0x6c, 0x2e, 0x34, 0x99, 0x4c, 0xab, 0x04, 0x41, 
+	0x58, 0x87, 0x02, 0xbd, 0x5e, 0xef, 0x71, 0x56, 0x89, 0x12, 0x38, 0x9f, 
+	0x5b, 0x5b, 0x5b, 0xab, 0xd9, 0xd0, 0xe9, 0x4e, 0xf8, 0x35, 0x0a, 0xea, 
+	0xd2, 0x3a, 0x22, 0x0f, 0xfa, 0xe2, 0x8b, 0x2f, 0xde, 0x91, 0x99, 0x5f, 
+	0x8b, 0xb6, 0x83, 0x07, 0x42, 0x48, 0x5e, 0x5e, 0x5e, 0x9e, 0x5d, 0x36, 
+	0xf1, 0x49, 0xf7, 0x42, 0x20, 0x19, 0x4c, 0x8e, 0xc4, 0xdc, 0xdc, 0xdc, 
+	0x79, 0x36, 0x9b, 0x2d, 0xdf, 0x6c, 0x36, 0x2f, 0x33, 0x99, 0x4c, 0xff, 
+	0x48, 0x08, 0x81, 0x10, 0x36, 0x9c, 0x3b, 0x77, 0xee, 0x17, 0x92, 0x24, 
+	0x8d, 0xb1, 0x0a, 0x95, 0x80, 0x20, 0x2a, 0x2a, 0x2a, 0x66, 0x44, 0x23, 
+	0x52, 0x31, 0x8e, 0x36, 0xb5, 0xb7, 0xb7, 0x37, 0x28, 0x98, 0xff, 0x07, 
+	0x42, 0x08, 0x8e, 0xb9, 0x2d, 0x20, 0x84, 0x60, 0xa9, 0x2a, 0xca, 0xcc, 
+	0x73, 0x7a, 0xcc, 0x2b, 0x69, 0x42, 0x08, 0x84, 0x90, 0x7c, 0x51, 0x14, 
+	0x1f, 0x96, 0x0b, 0x84, 0x10, 0x1e, 0xab, 0xac, 0xac, 0x2c, 0x0f, 0x04, 
+	0x02, 0x5f, 0xb3, 0xca, 0xb5, 0x00, 0xd3, 0xc0, 0x0f, 0x9e, 0xa6, 0xf2, 
+	0x33, 0x1a, 0x7c, 0x8b, 0x1f, 0x5e, 0x41, 0xd5, 0xf5, 0x18, 0x07, 0x0d, 
+	0x0f, 0x0f, 0x77, 0xa1, 0x4d, 0x46, 0xa3, 0x51, 0xd9, 0xf3, 0x60, 0x3e, 
+	0x5f, 0xc1, 0xbc, 0x66, 0xe4, 0x44, 0x4f, 0x1a, 0x9c, 0x2c, 0x2d, 0xa3, 
+	0xfc, 0xb1, 0x45, 0xb6, 0x1f, 0x24, 0x51, 0x10, 0x04, 0x13, 0xa5, 0x54, 
+	0x38, 0x75, 0xea, 0xd4, 0x96, 0xd2, 0xd2, 0xd2, 0x2a, 0x8b, 0xc5, 0xa2, 
+	0xbf, 0x44, 0x93, 0x4f, 0x9d, 0xe0, 0x70, 0x02, 0x3b, 0x7d, 0x82, 0x21, 
+	0x54, 0x3d, 0x82, 0xb0, 0x9f, 0xcc, 0xc1, 0xa3, 0x23, 0x21, 0x80, 0xa9, 
+	0x8e, 0x4f, 0xaa, 0x81, 0x09, 0x4e, 0x67, 0x67, 0xe7, 0x89, 0x4d, 0x9b, 
+	0x36, 0xbd, 0x2b, 0x7b, 0xf5, 0x08, 0x3c, 0x7e, 0x24, 0x12, 0x09, 0xda, 
+	0x6c, 0xb6, 0x80, 0xdf, 0xef, 0xc7, 0xa6, 0x40, 0x04, 0x4e, 0x8f, 0x52, 
+	0x1a, 0xe7, 0x38, 0x2e, 0xfd, 0x8d, 0x0c, 0xf8, 0x04, 0x97, 0xcb, 0x05, 
+	0x01, 0x98, 0x65, 0x29, 0xe6, 0xcb, 0x87, 0xa8, 0x57, 0xca, 0xbf, 0x03, 
+	0x5e, 0x4f, 0x08, 0xd9, 0x08, 0xc9, 0xf7, 0xf4, 0xf4, 0x34, 0xeb, 0x99, 
+	0xc5, 0x4c, 0x00, 0x75, 0x75, 0x75, 0x75, 0xbd, 0xb1, 0x71, 0xe3, 0xc6, 
+	0x2d, 0x84, 0x10, 0xac, 0x5b, 0x1e, 0x15, 0x04, 0xc1, 0x09, 0x87, 0x27, 
+	0xdb, 0x7c, 0xbe, 0xac, 0xbd, 0x66, 0xb9, 0xe7, 0x75, 0xd5, 0x5e, 0xf7, 
+	0x01, 0x3b, 0x42, 0x2b, 0xbf, 0x63, 0x90, 0x0b, 0x63, 0xda, 0x60, 0x96, 
+	0x93, 0x20, 0x3b, 0x16, 0xc3, 0xb3, 0xcf, 0x3e, 0xbb, 0xb0, 0xbc, 0xbc, 
+	0x7c, 0x4d, 0x69, 0x69, 0x69, 0x65, 0x2a, 0x8d, 0xb8, 0x5f, 0xa0, 0xc7, 
+	0x2f, 0x5e, 0xbc, 0xf8, 0xde, 0xfe, 0xfd, 0xfb, 0x9b, 0xdb, 0xdb, 0xdb, 
+	0x47, 0xe5, 0x5e, 0x47, 0x8a, 0x88, 0xa2, 0x88, 0xb1, 0x3e, 0x64, 0x34, 
+	0x1a, 0x83, 0x26, 0x93, 0x29, 0x7c, 0xeb, 0xd6, 0xad, 0x89, 0xb1, 0x5e, 
+	0x36, 0xa9, 0xcc, 0x04, 0x20, 0x83, 0x93, 0x05, 0x01, 0x41, 0x09, 0x79, 
+	0x79, 0x79, 0xc2, 0xf0, 0xf0, 0xb0, 0x68, 0xb1, 0x58, 0xcc, 0xc1, 0x60, 
+	0x90, 0x09, 0xc4, 0x24, 0x0b, 0x42, 0x30, 0x1a, 0x8d, 0x86, 0x68, 0x34, 
+	0x4a, 0x8e, 0x1c, 0x39, 0xb2, 0x66, 0xeb, 0xd6, 0xad, 0x5b, 0xf2, 0xf3, 
+	0xf3, 0x9d, 0x53, 0x15, 0x46, 0x30, 0x18, 0xbc, 0x7e, 0xf5, 0xea, 0xd5, 
+	0x73, 0xa7, 0x4f, 0x9f, 0x3e, 0xb7, 0x6f, 0xdf, 0xbe, 0x2e, 0x99, 0xa1, 
+	0x98, 0x9c, 0x22, 0x72, 0x0a, 0x5b, 0x2c, 0x96, 0x50, 0x30, 0x18, 0x0c, 
+	0xc9, 0x93, 0x1c, 0xa9, 0xbc, 0xbc, 0x3c, 0xda, 0xdc, 0xdc, 0x7c, 0xcf, 
+	0x58, 0x9f, 0xa9, 0x00, 0x26, 0xcc, 0x01, 0xa7, 0xc9, 0x9b, 0x9b, 0x9b, 
+	0x0d, 0xb2, 0x5f, 0x00, 0xd3, 0x22, 0x42, 0x49, 0x94, 0x52, 0x53, 0x38, 
+	0x1c, 0x4e, 0xf8, 0x05, 0x49, 0x92, 0x8c, 0xb2, 0xb6, 0xf0, 0x72, 0x22, 
+	0x7b, 0xf6, 0xec, 0x59, 0x58, 0x56, 0x56, 0x56, 0xb4, 0x62, 0xc5, 0x8a, 
+	0xa2, 0x82, 0x82, 0x82, 0x22, 0xbb, 0xdd, 0x5e, 0x20, 0x08, 0x82, 0x5d, 
+	0x2d, 0x18, 0x30, 0x2a, 0x49, 0xd2, 0xf8, 0xf8, 0xf8, 0xf8, 0xf5, 0xd1, 
+	0xd1, 0xd1, 0xeb, 0xfd, 0xfd, 0xfd, 0x5f, 0xbe, 0xfd, 0xf6, 0xdb, 0xde, 
+	0xa6, 0xa6, 0xa6, 0xeb, 0x46, 0xa3, 0x91, 0x46, 0xa3, 0xd1, 0xb8, 0xd1, 
+	0x68, 0x8c, 0x47, 0xa3, 0xd1, 0x98, 0x3c, 0x91, 0x91, 0x44, 0x51, 0x0c, 
+	0x87, 0xc3, 0x61, 0x08, 0x20, 0xe4, 0x70, 0x38, 0x42, 0x26, 0x93, 0x29, 
+	0x3a, 0x34, 0x34, 0x14, 0x51, 0x08, 0x29, 0x25, 0xfe, 0x3e, 0x00, 0x1a, 
+	0x4d, 0x49, 0x25, 0x37, 0x9e, 0x0d, 0x95, 0x00, 0x00, 0x00, 0x00, 0x49, 
+	0x45, 0x4e, 0x44, 0xae, 0x42, 0x60, 0x82, 
+}
+
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// recordings where no 10ms frame is louder than this RMS level are considered
+// silent, usually a muted microphone or the wrong input device
+const silentRecordingLevel = 0.003
+
+// recordings with more than this fraction of samples at full scale are
+// considered clipped
+const clippedRecordingFraction = 0.001
+
+// how often the level of the input is published while recording
+const levelInterval = 50 * time.Millisecond
+
+// AudioLevel is the loudness of a span of audio, RMS and Peak are from 0 to 1
+type AudioLevel struct {
+	RMS  float64
+	Peak float64
+	// some samples were at full scale, the input gain is too high
+	Clipped bool
+}
+
+// the RMS level in decibels relative to full scale
+func (l AudioLevel) Decibels() float64 {
+	if l.RMS <= 0 {
+		return -96
+	}
+	return max(-96, 20*math.Log10(l.RMS))
+}
+
+// a text bar graph of the level for the tray, covering -60dB to 0dB
+func (l AudioLevel) Meter(width int) string {
+	filled := int(math.Round((l.Decibels() + 60) / 60 * float64(width)))
+	filled = min(max(filled, 0), width)
+
+	meter := strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+	if l.Clipped {
+		meter += " CLIP"
+	}
+	return meter
+}
+
+func isClippedSample(s int16) bool {
+	return s == math.MaxInt16 || s == math.MinInt16
+}
+
+func measureLevel(samples []int16) AudioLevel {
+	var level AudioLevel
+	if len(samples) == 0 {
+		return level
+	}
+
+	var sumSquares float64
+	for _, s := range samples {
+		v := float64(s) / 32768
+		sumSquares += v * v
+		level.Peak = max(level.Peak, math.Abs(v))
+		if isClippedSample(s) {
+			level.Clipped = true
+		}
+	}
+
+	level.RMS = math.Sqrt(sumSquares / float64(len(samples)))
+	return level
+}
+
+// levelMeter collects audio buffers into windows of levelInterval and calls
+// publish with the level of each window. Safe to use from the audio callback
+type levelMeter struct {
+	publish    func(AudioLevel)
+	windowSize int
+
+	count      int
+	sumSquares float64
+	level      AudioLevel
+}
+
+func newLevelMeter(sampleRate int, publish func(AudioLevel)) *levelMeter {
+	return &levelMeter{
+		publish:    publish,
+		windowSize: max(1, int(levelInterval.Seconds()*float64(sampleRate))),
+	}
+}
+
+func (m *levelMeter) Process(in []int16) {
+	for _, s := range in {
+		v := float64(s) / 32768
+		m.sumSquares += v * v
+		m.level.Peak = max(m.level.Peak, math.Abs(v))
+		if isClippedSample(s) {
+			m.level.Clipped = true
+		}
+
+		m.count++
+		if m.count == m.windowSize {
+			m.level.RMS = math.Sqrt(m.sumSquares / float64(m.count))
+			m.publish(m.level)
+
+			m.count = 0
+			m.sumSquares = 0
+			m.level = AudioLevel{}
+		}
+	}
+}
+
+// summary of the level of a whole recording, used to warn about input problems
+// before uploading
+type RecordingLevel struct {
+	AudioLevel
+	// RMS of the loudest 10ms frame
+	LoudestFrame float64
+	// fraction of samples at full scale
+	ClippedFraction float64
+}
+
+func analyzeRecording(samples []int16, sampleRate int) RecordingLevel {
+	result := RecordingLevel{
+		AudioLevel: measureLevel(samples),
+	}
+
+	frameSize := max(1, sampleRate/100)
+	for offset := 0; offset < len(samples); offset += frameSize {
+		rms, _ := frameStats(samples[offset:min(offset+frameSize, len(samples))])
+		result.LoudestFrame = max(result.LoudestFrame, rms)
+	}
+
+	if result.Clipped {
+		clipped := 0
+		for _, s := range samples {
+			if isClippedSample(s) {
+				clipped++
+			}
+		}
+		result.ClippedFraction = float64(clipped) / float64(len(samples))
+	}
+
+	return result
+}
+
+// nothing louder than background noise was recorded
+func (r RecordingLevel) Silent() bool {
+	return r.LoudestFrame < silentRecordingLevel
+}
+
+// enough of the recording was at full scale to distort speech
+func (r RecordingLevel) HeavilyClipped() bool {
+	return r.ClippedFraction > clippedRecordingFraction
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestAnalyzeRecording(t *testing.T) {
+	const sampleRate = 16000
+
+	// one full scale sample in a second of speech
+	oneClipped := testSignal(sampleRate, time.Second, 0.3, 440, 0)
+	oneClipped[100] = math.MaxInt16
+
+	tests := []struct {
+		name           string
+		samples        []int16
+		silent         bool
+		clipped        bool
+		heavilyClipped bool
+	}{
+		{"empty", nil, true, false, false},
+		{"digital silence", make([]int16, sampleRate), true, false, false},
+		{"muted microphone", testSignal(sampleRate, time.Second, 0, 0, 0.0005), true, false, false},
+		{"speech", testSignal(sampleRate, time.Second, 0.3, 440, 0.001), false, false, false},
+		{"speech then silence", slices.Concat(testSignal(sampleRate, 200*time.Millisecond, 0.3, 440, 0), make([]int16, 2*sampleRate)), false, false, false},
+		{"one clipped sample", oneClipped, false, true, false},
+		{"clipped", testSignal(sampleRate, time.Second, 2, 440, 0), false, true, true},
+	}
+
+	for _, test := range tests {
+		level := analyzeRecording(test.samples, sampleRate)
+
+		if level.Silent() != test.silent {
+			t.Errorf("%s: silent %v, want %v (loudest frame %.4f)", test.name, level.Silent(), test.silent, level.LoudestFrame)
+		}
+		if level.Clipped != test.clipped {
+			t.Errorf("%s: clipped %v, want %v", test.name, level.Clipped, test.clipped)
+		}
+		if level.HeavilyClipped() != test.heavilyClipped {
+			t.Errorf("%s: heavily clipped %v, want %v (%.4f clipped)", test.name, level.HeavilyClipped(), test.heavilyClipped, level.ClippedFraction)
+		}
+	}
+
+	// a tone at 0.3 of full scale has an RMS of 0.3/√2 in every frame
+	level := analyzeRecording(testSignal(sampleRate, time.Second, 0.3, 400, 0), sampleRate)
+	if expected := 0.3 / math.Sqrt2; math.Abs(level.LoudestFrame-expected) > 0.01 || math.Abs(level.RMS-expected) > 0.01 {
+		t.Errorf("loudest frame %.4f and RMS %.4f, want %.4f", level.LoudestFrame, level.RMS, expected)
+	}
+}
@@ -6,23 +6,30 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"strings"
+	"time"
 
 	"flag"
 
 	"github.com/getlantern/systray"
-	"github.com/go-vgo/robotgo"
-	"golang.design/x/hotkey"
 )
 
 var DEFAULT_TITLE = "TalkXTyper"
 
 func main() {
 	help := flag.Bool("help", false, "Show this help message")
-	nvimTest := flag.String("nvim-test", "", "Test nvim integration (possible values: insertion, visible, mode, title)")
+	nvimTest := flag.String("nvim-test", "", "Test nvim integration (possible values: insertion, visible, mode, title, discover)")
 	oneShot := flag.Bool("one-shot", false, "Run the record task blocking in console, don't start any background systems")
 	reportScreen := flag.Bool("report-screen", false, "Test screen description system, and exit")
 	audioDevices := flag.Bool("audio-devices", false, "Print out all audio devices and exit")
 	transcribeFname := flag.String("transcribe", "", "Transcribe audio from the specified file")
+	rerunUUID := flag.String("rerun", "", "Run the history entry with this UUID through transcription and repair again, saving the result as a new revision")
+	rerunSteps := flag.String("rerun-steps", "transcribe,repair", "Steps to run again for -rerun: transcribe, repair, or both separated by a comma. By default repair only runs for entries that have a repair prompt")
+	rerunBackend := flag.String("rerun-backend", "", "Transcription backend for -rerun, defaults to TranscriptionBackend")
+	rerunPrompt := flag.String("rerun-prompt", "", "Repair instructions for -rerun, defaults to the entry's repair prompt")
+	exportFormat := flag.String("export", "", "Export the history and exit (possible values: jsonl, csv, markdown, srt, vtt, zip)")
+	exportFile := flag.String("export-file", "", "File to write -export to, defaults to stdout")
+	exportUUID := flag.String("export-uuid", "", "Only export the history entry with this UUID, required for srt and vtt")
 
 	flag.Parse()
 
@@ -32,12 +39,39 @@ func main() {
 	}
 
 	if *audioDevices {
-		log.Println("Available pipewire devices:")
+		readConfig()
+		log.Println("Available audio devices:")
 		debugAudioDevices()
 		return
 	}
 
+	if *nvimTest == "discover" {
+		readConfig()
+
+		searches := []struct {
+			name     string
+			discover func() (*nvimDiscovery, error)
+		}{
+			{"Active window", discoverActiveNvim},
+			{"Any nvim", discoverAnyNvim},
+		}
+
+		for _, search := range searches {
+			fmt.Printf("%s:\n", search.name)
+			discovery, err := search.discover()
+			discovery.Report(os.Stdout)
+			if err != nil {
+				fmt.Printf("Not found: %v\n\n", err)
+			} else {
+				fmt.Printf("Using: %s\n\n", discovery.Address)
+			}
+		}
+		return
+	}
+
 	if *nvimTest != "" {
+		readConfig()
+
 		client := NewNvimClient()
 		err := client.FindFirstNvim()
 
@@ -100,12 +134,96 @@ func main() {
 		return
 	}
 
+	if *exportFormat != "" {
+		format, err := getHistoryExportFormat(*exportFormat)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		dir, err := getHistoryDir()
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		// exporting doesn't apply retention or compact the history
+		history, err := openHistoryStore(dir, true)
+		if err != nil {
+			log.Fatalf("Error opening history: %v", err)
+		}
+		entries := history.List()
+
+		if *exportUUID != "" {
+			entry := history.Get(*exportUUID)
+			if entry == nil {
+				log.Fatalf("History entry not found: %s", *exportUUID)
+			}
+			entries = []*TranscriptionResult{entry}
+		}
+
+		if err := exportHistoryToFile(*exportFile, format, history, entries); err != nil {
+			log.Fatalf("Error exporting history: %v", err)
+		}
+
+		if *exportFile != "" {
+			log.Printf("Exported %d entries to %s", len(entries), *exportFile)
+		}
+		return
+	}
+
+	if err := taskManager.OpenHistory(); err != nil {
+		log.Printf("History won't be saved: %v", err)
+	}
+
+	if *rerunUUID != "" {
+		options := RerunOptions{
+			Backend:      *rerunBackend,
+			RepairPrompt: *rerunPrompt,
+		}
+
+		for _, step := range strings.Split(*rerunSteps, ",") {
+			switch strings.TrimSpace(step) {
+			case "transcribe":
+				options.Transcribe = true
+			case "repair":
+				options.Repair = true
+			default:
+				log.Fatalf("Invalid rerun step: %s", step)
+			}
+		}
+
+		stepsSet := false
+		flag.Visit(func(f *flag.Flag) {
+			stepsSet = stepsSet || f.Name == "rerun-steps"
+		})
+
+		// a prompt implies repairing, otherwise the default steps only repair
+		// entries that were repaired the first time
+		if options.RepairPrompt != "" {
+			options.Repair = true
+		} else if !stepsSet {
+			if parent := taskManager.History().Get(*rerunUUID); parent != nil && parent.RepairPrompt == "" {
+				options.Repair = false
+			}
+		}
+
+		result, err := rerunHistoryEntry(context.Background(), *rerunUUID, options)
+		if err != nil {
+			log.Fatalf("Error running history entry again: %v", err)
+		}
+
+		fmt.Printf("Revision %s (%s):\n%s\n", result.UUID, result.Backend, result.String())
+		return
+	}
+
 	if config.ListenAddress != "" {
 		go startServer()
 	}
 
 	onExit := func() {
 		log.Println("Exiting...")
+		if err := taskManager.StopCapture(); err != nil {
+			log.Printf("Error: %v", err)
+		}
 	}
 	// note this takes over the main loop
 	systray.Run(onReady, onExit)
@@ -116,12 +234,25 @@ func oneShotMode() {
 	readConfig()
 
 	// force disable any transcription fixing
-	config.IncludeScreen = false
-	config.IncludeNvim = false
+	for _, option := range contextProviders {
+		*option.Enabled = false
+	}
+	// the result is printed once complete, there's nothing to stream to
+	config.StreamingMode = false
 
-	log.Println("Now recording... (Press Ctrl+C or ESC to stop)")
+	if err := taskManager.OpenHistory(); err != nil {
+		log.Printf("History won't be saved: %v", err)
+	}
+
+	if stopKeys := config.Hotkeys[HotkeyActionOneShotStop]; stopKeys != "" {
+		log.Printf("Now recording... (Press Ctrl+C or %s to stop)\n", stopKeys)
+	} else {
+		log.Println("Now recording... (Press Ctrl+C to stop)")
+	}
 
-	stopHotkey := hotkey.New(nil, hotkey.KeyEscape)
+	stopEvents := make(chan hotkeyEvent, 1)
+	// non-zero when there's no transcription to print
+	exitCode := 0
 
 	systray.Run(func() {
 		systray.SetIcon(icon_blue)
@@ -130,7 +261,7 @@ func oneShotMode() {
 
 		mAbort := systray.AddMenuItem("Abort", "Cancel operation and don't return anything")
 
-		stopHotkey.Register()
+		stopBindings := registerHotkeys([]string{HotkeyActionOneShotStop}, stopEvents)
 
 		go func() {
 			for {
@@ -154,7 +285,7 @@ func oneShotMode() {
 			}
 		}()
 
-		taskManager.StartNewTask()
+		task := taskManager.StartNewTask(TaskOptions{})
 
 		// Listen for CTRL-C to stop the task
 		c := make(chan os.Signal, 1)
@@ -163,18 +294,34 @@ func oneShotMode() {
 		select {
 		case <-c:
 			break
-		case <-stopHotkey.Keydown():
-			stopHotkey.Unregister()
+		case <-stopEvents:
 			break
 		}
 
+		unregisterHotkeys(stopBindings)
+
 		log.Println("Stopping recording...")
 		taskManager.StopRecording()
 
 		log.Println("Waiting for transcription...")
 		select {
-		case transcription := <-taskManager.transcriptionRes:
-			fmt.Println(transcription)
+		case <-task.waitForCompletion:
+			if task.GetResult() != nil {
+				fmt.Println(<-taskManager.transcriptionRes)
+				break
+			}
+
+			exitCode = 1
+			if uuid := task.GetFailedUUID(); uuid != "" {
+				if failed := taskManager.History().Get(uuid); failed != nil {
+					log.Printf("Error: %s", failed.Error)
+				}
+				log.Printf("The recording was saved to the history as %s, run it again with -rerun %s", uuid, uuid)
+			} else if warning := task.GetWarning(); warning != "" {
+				log.Printf("No transcription: %s", warning)
+			} else {
+				log.Println("No transcription")
+			}
 		case <-c:
 			log.Println("CTRL-C received")
 		}
@@ -183,6 +330,8 @@ func oneShotMode() {
 	}, func() {
 		log.Println("Exiting...")
 	})
+
+	os.Exit(exitCode)
 }
 
 func onReady() {
@@ -193,74 +342,216 @@ func onReady() {
 	mRecord := systray.AddMenuItem("Record and Transcribe", "Start recording and transcribing")
 	mAbort := systray.AddMenuItem("Abort Recording", "Abort the current recording")
 	mAbort.Hide()
+	mRetry := systray.AddMenuItem("Retry Transcription", "Transcribe the recording that failed again and type the result")
+	mRetry.Hide()
+
+	// clicks on any of the context provider checkboxes send the provider's
+	// index
+	contextToggles := make(chan int)
+	var contextItems []*systray.MenuItem
+	for i, option := range contextProviders {
+		item := systray.AddMenuItemCheckbox(option.Label, option.Tooltip, *option.Enabled)
+		contextItems = append(contextItems, item)
+
+		go func(i int, item *systray.MenuItem) {
+			for range item.ClickedCh {
+				contextToggles <- i
+			}
+		}(i, item)
+	}
 
-	mIncludeScreen := systray.AddMenuItemCheckbox("Include screen", "Analyze the screen to augment the transcription", config.IncludeScreen)
-	mIncludeNvim := systray.AddMenuItemCheckbox("Include nvim", "Include text from current nvim viewport in the transcription", config.IncludeNvim)
+	mStreaming := systray.AddMenuItemCheckbox("Streaming mode", "Type each part of the transcription at pauses while still recording", config.StreamingMode)
+	mPreRoll := systray.AddMenuItemCheckbox("Pre-roll", "Keep the microphone open so speech just before recording starts isn't cut off", config.PreRoll)
 
 	mExit := systray.AddMenuItem("Exit", "Exit the application")
 
-	// setup hotkeys
-	toggleHotkey := hotkey.New([]hotkey.Modifier{hotkey.Mod1}, hotkey.KeyB)
-	toggleHotkey.Register()
+	if err := taskManager.UpdateCapture(); err != nil {
+		log.Printf("Pre-roll disabled: %v", err)
+	}
+
+	hotkeyEvents := make(chan hotkeyEvent, 16)
+	registerHotkeys(trayHotkeyActions, hotkeyEvents)
 
-	abortHotkey := hotkey.New([]hotkey.Modifier{hotkey.Mod1}, hotkey.KeyC)
-	abortHotkey.Register()
+	// refreshes the input level shown in the tooltip while recording
+	levelTicker := time.NewTicker(4 * levelInterval)
 
 	go func() {
+		// tooltip for the current state, the level meter is appended to it
+		var recordingTooltip string
+		// the history entry that was last erased with undo-last, so it's only
+		// erased once
+		var undoneUUID string
+		// the failed history entry retried from the menu
+		var retryUUID string
+
 		for {
 			select {
 			case state := <-taskManager.stateCh:
+				recordingTooltip = ""
 				switch state {
 				case TaskStateRecording:
+					recordingTooltip = "Recording audio..."
 					systray.SetIcon(icon_red)
-					systray.SetTooltip("Recording audio...")
+					systray.SetTooltip(recordingTooltip)
+					mRecord.SetTitle("Stop recording")
+					mAbort.Show()
+				case TaskStateStreaming:
+					recordingTooltip = "Streaming transcription..."
+					systray.SetIcon(icon_yellow)
+					systray.SetTooltip(recordingTooltip)
 					mRecord.SetTitle("Stop recording")
 					mAbort.Show()
 				case TaskStateTranscribing:
-					systray.SetTooltip("Transcribing audio...")
+					if taskManager.GetStatus().StopReason == StopReasonSilence {
+						systray.SetTooltip("Transcribing audio (stopped on silence)...")
+					} else {
+						systray.SetTooltip("Transcribing audio...")
+					}
 					systray.SetIcon(icon_green)
 				default:
-					systray.SetTooltip("Ready")
+					status := taskManager.GetStatus()
+					if status.Warning != "" {
+						systray.SetTooltip("Ready\n" + status.Warning)
+					} else {
+						systray.SetTooltip("Ready")
+					}
 					systray.SetIcon(icon_blue)
 					mRecord.SetTitle("Record and Transcribe")
 					mAbort.Hide()
+
+					retryUUID = status.FailedUUID
+					if retryUUID != "" {
+						mRetry.Show()
+					} else {
+						mRetry.Hide()
+					}
+				}
+
+			case <-levelTicker.C:
+				if recordingTooltip == "" {
+					continue
+				}
+				if level := taskManager.GetLevel(); level != nil {
+					systray.SetTooltip(fmt.Sprintf("%s\n%s %.0f dB", recordingTooltip, level.Meter(20), level.Decibels()))
 				}
 
 			case transcription := <-taskManager.transcriptionRes:
-				typeString(transcription.String())
+				writeOutput(transcription.Output, transcription.String())
 
-			case <-toggleHotkey.Keydown():
-				taskManager.StartOrStopTask()
+			case update := <-taskManager.streamUpdates:
+				applyStreamUpdate(update)
 
-			case <-abortHotkey.Keydown():
-				taskManager.Abort()
+			case event := <-hotkeyEvents:
+				action := event.Action
+				if action == HotkeyActionToggle && config.RecordHotkeyMode == HotkeyModePushToTalk {
+					action = HotkeyActionPushToTalk
+				}
+
+				options := TaskOptions{Output: config.HotkeyOutputs[event.Action]}
+
+				if action == HotkeyActionPushToTalk {
+					if event.Pressed {
+						taskManager.StartTaskIfIdle(options)
+					} else {
+						taskManager.StopRecording()
+					}
+					continue
+				}
+
+				if !event.Pressed {
+					continue
+				}
+
+				switch action {
+				case HotkeyActionToggle:
+					taskManager.StartOrStopTask(options)
+				case HotkeyActionRecordWithScreen:
+					options.ForceScreen = true
+					taskManager.StartOrStopTask(options)
+				case HotkeyActionRecordRaw:
+					options.Raw = true
+					taskManager.StartOrStopTask(options)
+				case HotkeyActionAbort:
+					taskManager.Abort()
+				case HotkeyActionRetypeLast:
+					if last := taskManager.LastOutput(); last != nil {
+						writeOutput(last.Output, last.String())
+						undoneUUID = ""
+					}
+				case HotkeyActionUndoLast:
+					if last := taskManager.LastOutput(); last != nil {
+						if last.UUID != undoneUUID {
+							eraseOutput(last.Output, len([]rune(last.String())))
+							undoneUUID = last.UUID
+						}
+					}
+				}
 
 			case <-mRecord.ClickedCh:
-				taskManager.StartOrStopTask()
+				taskManager.StartOrStopTask(TaskOptions{})
 			case <-mAbort.ClickedCh:
 				taskManager.Abort()
+			case <-mRetry.ClickedCh:
+				mRetry.Hide()
+				systray.SetTooltip("Retrying transcription...")
+				systray.SetIcon(icon_green)
+
+				go func(uuid string) {
+					result, err := retryHistoryEntry(context.Background(), uuid)
+					systray.SetIcon(icon_blue)
+
+					if err != nil {
+						log.Printf("Error retrying transcription: %v", err)
+						systray.SetTooltip(fmt.Sprintf("Ready\nRetry failed: %v", err))
+						mRetry.Show()
+						return
+					}
+
+					systray.SetTooltip("Ready")
+					if !result.Streamed {
+						taskManager.transcriptionRes <- result
+					}
+				}(retryUUID)
 
-			case <-mIncludeScreen.ClickedCh:
-				if mIncludeScreen.Checked() {
-					mIncludeScreen.Uncheck()
+			case i := <-contextToggles:
+				item := contextItems[i]
+				if item.Checked() {
+					item.Uncheck()
 				} else {
-					mIncludeScreen.Check()
+					item.Check()
 				}
 
-				config.IncludeScreen = mIncludeScreen.Checked()
+				*contextProviders[i].Enabled = item.Checked()
 
 				if err := writeConfig(); err != nil {
 					fmt.Fprintf(os.Stderr, "Error writing config: %v\n", err)
 				}
 
-			case <-mIncludeNvim.ClickedCh:
-				if mIncludeNvim.Checked() {
-					mIncludeNvim.Uncheck()
+			case <-mStreaming.ClickedCh:
+				if mStreaming.Checked() {
+					mStreaming.Uncheck()
 				} else {
-					mIncludeNvim.Check()
+					mStreaming.Check()
 				}
 
-				config.IncludeNvim = mIncludeNvim.Checked()
+				config.StreamingMode = mStreaming.Checked()
+
+				if err := writeConfig(); err != nil {
+					fmt.Fprintf(os.Stderr, "Error writing config: %v\n", err)
+				}
+
+			case <-mPreRoll.ClickedCh:
+				if mPreRoll.Checked() {
+					mPreRoll.Uncheck()
+				} else {
+					mPreRoll.Check()
+				}
+
+				config.PreRoll = mPreRoll.Checked()
+
+				if err := taskManager.UpdateCapture(); err != nil {
+					log.Printf("Pre-roll disabled: %v", err)
+				}
 
 				if err := writeConfig(); err != nil {
 					fmt.Fprintf(os.Stderr, "Error writing config: %v\n", err)
@@ -273,8 +564,3 @@ func onReady() {
 		}
 	}()
 }
-
-func typeString(input string) error {
-	robotgo.TypeStr(input, 0, 2)
-	return nil
-}
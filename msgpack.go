@@ -0,0 +1,465 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+)
+
+// a minimal MessagePack encoder and decoder, enough for talking msgpack-RPC to
+// nvim. Values decode to nil, bool, int64, uint64, float64, string, []byte,
+// []interface{}, map[string]interface{} and MsgpackExt
+
+// MsgpackExt is an extension value. nvim uses these for buffer, window and
+// tabpage handles
+type MsgpackExt struct {
+	Type int8
+	Data []byte
+}
+
+// the handle number of an nvim buffer, window or tabpage extension value
+func (e MsgpackExt) Handle() (int64, error) {
+	value, err := newMsgpackDecoder(bytes.NewReader(e.Data)).Decode()
+	if err != nil {
+		return 0, err
+	}
+	return msgpackInt(value)
+}
+
+type msgpackEncoder struct {
+	w   *bufio.Writer
+	buf [9]byte
+}
+
+func newMsgpackEncoder(w io.Writer) *msgpackEncoder {
+	return &msgpackEncoder{w: bufio.NewWriter(w)}
+}
+
+// encode a value and flush it to the writer
+func (e *msgpackEncoder) Encode(value interface{}) error {
+	if err := e.encode(value); err != nil {
+		return err
+	}
+	return e.w.Flush()
+}
+
+func (e *msgpackEncoder) encode(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		return e.w.WriteByte(0xc0)
+	case bool:
+		if v {
+			return e.w.WriteByte(0xc3)
+		}
+		return e.w.WriteByte(0xc2)
+	case int:
+		return e.encodeInt(int64(v))
+	case int8:
+		return e.encodeInt(int64(v))
+	case int16:
+		return e.encodeInt(int64(v))
+	case int32:
+		return e.encodeInt(int64(v))
+	case int64:
+		return e.encodeInt(v)
+	case uint:
+		return e.encodeUint(uint64(v))
+	case uint8:
+		return e.encodeUint(uint64(v))
+	case uint16:
+		return e.encodeUint(uint64(v))
+	case uint32:
+		return e.encodeUint(uint64(v))
+	case uint64:
+		return e.encodeUint(v)
+	case float32:
+		return e.encodeFloat(float64(v))
+	case float64:
+		return e.encodeFloat(v)
+	case string:
+		return e.encodeString(v)
+	case []byte:
+		return e.encodeBinary(v)
+	case []string:
+		if err := e.encodeArrayHeader(len(v)); err != nil {
+			return err
+		}
+		for _, item := range v {
+			if err := e.encodeString(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []interface{}:
+		if err := e.encodeArrayHeader(len(v)); err != nil {
+			return err
+		}
+		for _, item := range v {
+			if err := e.encode(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case map[string]interface{}:
+		if err := e.encodeMapHeader(len(v)); err != nil {
+			return err
+		}
+		for key, item := range v {
+			if err := e.encodeString(key); err != nil {
+				return err
+			}
+			if err := e.encode(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case MsgpackExt:
+		return e.encodeExt(v)
+	default:
+		return fmt.Errorf("Can't encode %T as msgpack", value)
+	}
+}
+
+// write a marker followed by n bytes of v in big endian
+func (e *msgpackEncoder) writeSized(marker byte, v uint64, n int) error {
+	e.buf[0] = marker
+	for i := 0; i < n; i++ {
+		e.buf[n-i] = byte(v >> (8 * i))
+	}
+	_, err := e.w.Write(e.buf[:n+1])
+	return err
+}
+
+func (e *msgpackEncoder) encodeInt(v int64) error {
+	if v >= 0 {
+		return e.encodeUint(uint64(v))
+	}
+
+	switch {
+	case v >= -32:
+		return e.w.WriteByte(byte(v))
+	case v >= math.MinInt8:
+		return e.writeSized(0xd0, uint64(v), 1)
+	case v >= math.MinInt16:
+		return e.writeSized(0xd1, uint64(v), 2)
+	case v >= math.MinInt32:
+		return e.writeSized(0xd2, uint64(v), 4)
+	default:
+		return e.writeSized(0xd3, uint64(v), 8)
+	}
+}
+
+func (e *msgpackEncoder) encodeUint(v uint64) error {
+	switch {
+	case v <= 0x7f:
+		return e.w.WriteByte(byte(v))
+	case v <= math.MaxUint8:
+		return e.writeSized(0xcc, v, 1)
+	case v <= math.MaxUint16:
+		return e.writeSized(0xcd, v, 2)
+	case v <= math.MaxUint32:
+		return e.writeSized(0xce, v, 4)
+	default:
+		return e.writeSized(0xcf, v, 8)
+	}
+}
+
+func (e *msgpackEncoder) encodeFloat(v float64) error {
+	return e.writeSized(0xcb, math.Float64bits(v), 8)
+}
+
+func (e *msgpackEncoder) encodeString(v string) error {
+	var err error
+	switch n := len(v); {
+	case n <= 31:
+		err = e.w.WriteByte(0xa0 | byte(n))
+	case n <= math.MaxUint8:
+		err = e.writeSized(0xd9, uint64(n), 1)
+	case n <= math.MaxUint16:
+		err = e.writeSized(0xda, uint64(n), 2)
+	default:
+		err = e.writeSized(0xdb, uint64(n), 4)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = e.w.WriteString(v)
+	return err
+}
+
+func (e *msgpackEncoder) encodeBinary(v []byte) error {
+	var err error
+	switch n := len(v); {
+	case n <= math.MaxUint8:
+		err = e.writeSized(0xc4, uint64(n), 1)
+	case n <= math.MaxUint16:
+		err = e.writeSized(0xc5, uint64(n), 2)
+	default:
+		err = e.writeSized(0xc6, uint64(n), 4)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = e.w.Write(v)
+	return err
+}
+
+func (e *msgpackEncoder) encodeArrayHeader(n int) error {
+	switch {
+	case n <= 15:
+		return e.w.WriteByte(0x90 | byte(n))
+	case n <= math.MaxUint16:
+		return e.writeSized(0xdc, uint64(n), 2)
+	default:
+		return e.writeSized(0xdd, uint64(n), 4)
+	}
+}
+
+func (e *msgpackEncoder) encodeMapHeader(n int) error {
+	switch {
+	case n <= 15:
+		return e.w.WriteByte(0x80 | byte(n))
+	case n <= math.MaxUint16:
+		return e.writeSized(0xde, uint64(n), 2)
+	default:
+		return e.writeSized(0xdf, uint64(n), 4)
+	}
+}
+
+func (e *msgpackEncoder) encodeExt(v MsgpackExt) error {
+	var err error
+	switch n := len(v.Data); n {
+	case 1:
+		err = e.w.WriteByte(0xd4)
+	case 2:
+		err = e.w.WriteByte(0xd5)
+	case 4:
+		err = e.w.WriteByte(0xd6)
+	case 8:
+		err = e.w.WriteByte(0xd7)
+	case 16:
+		err = e.w.WriteByte(0xd8)
+	default:
+		switch {
+		case n <= math.MaxUint8:
+			err = e.writeSized(0xc7, uint64(n), 1)
+		case n <= math.MaxUint16:
+			err = e.writeSized(0xc8, uint64(n), 2)
+		default:
+			err = e.writeSized(0xc9, uint64(n), 4)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if err := e.w.WriteByte(byte(v.Type)); err != nil {
+		return err
+	}
+	_, err = e.w.Write(v.Data)
+	return err
+}
+
+// limits on lengths read from the peer, so a bad length can't make the
+// decoder allocate more than the connection could reasonably send
+const (
+	msgpackMaxBytes = 64 << 20
+	msgpackMaxItems = 1 << 20
+)
+
+type msgpackDecoder struct {
+	r   *bufio.Reader
+	buf [8]byte
+}
+
+func newMsgpackDecoder(r io.Reader) *msgpackDecoder {
+	return &msgpackDecoder{r: bufio.NewReader(r)}
+}
+
+// read the next complete value
+func (d *msgpackDecoder) Decode() (interface{}, error) {
+	marker, err := d.r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case marker <= 0x7f:
+		return int64(marker), nil
+	case marker >= 0xe0:
+		return int64(int8(marker)), nil
+	case marker&0xf0 == 0x80:
+		return d.decodeMap(int(marker & 0x0f))
+	case marker&0xf0 == 0x90:
+		return d.decodeArray(int(marker & 0x0f))
+	case marker&0xe0 == 0xa0:
+		return d.readString(int(marker & 0x1f))
+	}
+
+	switch marker {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := d.readLength(marker-0xc4, msgpackMaxBytes)
+		if err != nil {
+			return nil, err
+		}
+		return d.readBytes(n)
+	case 0xc7, 0xc8, 0xc9:
+		n, err := d.readLength(marker-0xc7, msgpackMaxBytes)
+		if err != nil {
+			return nil, err
+		}
+		return d.readExt(n)
+	case 0xca:
+		v, err := d.readUint(4)
+		return float64(math.Float32frombits(uint32(v))), err
+	case 0xcb:
+		v, err := d.readUint(8)
+		return math.Float64frombits(v), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		v, err := d.readUint(1 << (marker - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		if v <= math.MaxInt64 {
+			return int64(v), nil
+		}
+		return v, nil
+	case 0xd0:
+		v, err := d.readUint(1)
+		return int64(int8(v)), err
+	case 0xd1:
+		v, err := d.readUint(2)
+		return int64(int16(v)), err
+	case 0xd2:
+		v, err := d.readUint(4)
+		return int64(int32(v)), err
+	case 0xd3:
+		v, err := d.readUint(8)
+		return int64(v), err
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return d.readExt(1 << (marker - 0xd4))
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.readLength(marker-0xd9, msgpackMaxBytes)
+		if err != nil {
+			return nil, err
+		}
+		return d.readString(n)
+	case 0xdc, 0xdd:
+		n, err := d.readLength(marker-0xdc+1, msgpackMaxItems)
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeArray(n)
+	case 0xde, 0xdf:
+		n, err := d.readLength(marker-0xde+1, msgpackMaxItems)
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeMap(n)
+	}
+
+	return nil, fmt.Errorf("Invalid msgpack marker: 0x%x", marker)
+}
+
+func (d *msgpackDecoder) readUint(n int) (uint64, error) {
+	if _, err := io.ReadFull(d.r, d.buf[:n]); err != nil {
+		return 0, err
+	}
+
+	var v uint64
+	for _, b := range d.buf[:n] {
+		v = v<<8 | uint64(b)
+	}
+	return v, nil
+}
+
+// read a length field, size is 0, 1 or 2 for 8, 16 or 32 bits. Lengths over
+// limit are an error
+func (d *msgpackDecoder) readLength(size byte, limit int) (int, error) {
+	v, err := d.readUint(1 << size)
+	if err != nil {
+		return 0, err
+	}
+	if v > uint64(limit) {
+		return 0, fmt.Errorf("Msgpack length %d is over the limit of %d", v, limit)
+	}
+	return int(v), nil
+}
+
+func (d *msgpackDecoder) readBytes(n int) ([]byte, error) {
+	data := make([]byte, n)
+	if _, err := io.ReadFull(d.r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (d *msgpackDecoder) readString(n int) (string, error) {
+	data, err := d.readBytes(n)
+	return string(data), err
+}
+
+func (d *msgpackDecoder) readExt(n int) (MsgpackExt, error) {
+	extType, err := d.r.ReadByte()
+	if err != nil {
+		return MsgpackExt{}, err
+	}
+	data, err := d.readBytes(n)
+	return MsgpackExt{Type: int8(extType), Data: data}, err
+}
+
+func (d *msgpackDecoder) decodeArray(n int) ([]interface{}, error) {
+	items := make([]interface{}, n)
+	for i := range items {
+		item, err := d.Decode()
+		if err != nil {
+			return nil, err
+		}
+		items[i] = item
+	}
+	return items, nil
+}
+
+// non string keys are formatted as strings, nvim only uses string keys
+func (d *msgpackDecoder) decodeMap(n int) (map[string]interface{}, error) {
+	items := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		key, err := d.Decode()
+		if err != nil {
+			return nil, err
+		}
+		value, err := d.Decode()
+		if err != nil {
+			return nil, err
+		}
+
+		if s, ok := key.(string); ok {
+			items[s] = value
+		} else {
+			items[fmt.Sprint(key)] = value
+		}
+	}
+	return items, nil
+}
+
+// convert a decoded integer to int64
+func msgpackInt(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case int64:
+		return v, nil
+	case uint64:
+		if v > math.MaxInt64 {
+			return 0, fmt.Errorf("Integer out of range: %d", v)
+		}
+		return int64(v), nil
+	default:
+		return 0, fmt.Errorf("Expected integer, got %T", value)
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func encodeMsgpack(t *testing.T, value interface{}) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := newMsgpackEncoder(&buf).Encode(value); err != nil {
+		t.Fatalf("encoding %T: %v", value, err)
+	}
+	return buf.Bytes()
+}
+
+func decodeMsgpack(t *testing.T, data []byte) interface{} {
+	t.Helper()
+	value, err := newMsgpackDecoder(bytes.NewReader(data)).Decode()
+	if err != nil {
+		t.Fatalf("decoding %x: %v", data[:min(len(data), 16)], err)
+	}
+	return value
+}
+
+func TestMsgpackStrings(t *testing.T) {
+	tests := []struct {
+		length int
+		marker byte
+	}{
+		{0, 0xa0},
+		{31, 0xbf},
+		{32, 0xd9},
+		{255, 0xd9},
+		{256, 0xda},
+		{65535, 0xda},
+		{65536, 0xdb},
+	}
+
+	for _, test := range tests {
+		value := strings.Repeat("x", test.length)
+		data := encodeMsgpack(t, value)
+
+		if data[0] != test.marker {
+			t.Errorf("string of %d: marker %#x, want %#x", test.length, data[0], test.marker)
+		}
+		if decoded := decodeMsgpack(t, data); decoded != value {
+			t.Errorf("string of %d: decoded %d bytes", test.length, len(decoded.(string)))
+		}
+	}
+}
+
+func TestMsgpackInts(t *testing.T) {
+	tests := []struct {
+		value  int64
+		marker byte
+	}{
+		{0, 0x00},
+		{127, 0x7f},
+		{128, 0xcc},
+		{255, 0xcc},
+		{256, 0xcd},
+		{65535, 0xcd},
+		{65536, 0xce},
+		{math.MaxUint32, 0xce},
+		{math.MaxUint32 + 1, 0xcf},
+		{math.MaxInt64, 0xcf},
+		{-1, 0xff},
+		{-32, 0xe0},
+		{-33, 0xd0},
+		{math.MinInt8, 0xd0},
+		{math.MinInt8 - 1, 0xd1},
+		{math.MinInt16, 0xd1},
+		{math.MinInt16 - 1, 0xd2},
+		{math.MinInt32, 0xd2},
+		{math.MinInt32 - 1, 0xd3},
+		{math.MinInt64, 0xd3},
+	}
+
+	for _, test := range tests {
+		data := encodeMsgpack(t, test.value)
+
+		if data[0] != test.marker {
+			t.Errorf("%d: marker %#x, want %#x", test.value, data[0], test.marker)
+		}
+		if decoded := decodeMsgpack(t, data); decoded != test.value {
+			t.Errorf("%d: decoded %v (%T)", test.value, decoded, decoded)
+		}
+	}
+
+	// too large for int64
+	data := encodeMsgpack(t, uint64(math.MaxUint64))
+	if decoded := decodeMsgpack(t, data); decoded != uint64(math.MaxUint64) {
+		t.Errorf("max uint64: decoded %v (%T)", decoded, decoded)
+	}
+}
+
+func TestMsgpackArrays(t *testing.T) {
+	tests := []struct {
+		length int
+		marker byte
+	}{
+		{0, 0x90},
+		{15, 0x9f},
+		{16, 0xdc},
+		{65535, 0xdc},
+		{65536, 0xdd},
+	}
+
+	for _, test := range tests {
+		value := make([]interface{}, test.length)
+		for i := range value {
+			value[i] = int64(i % 200)
+		}
+		data := encodeMsgpack(t, value)
+
+		if data[0] != test.marker {
+			t.Errorf("array of %d: marker %#x, want %#x", test.length, data[0], test.marker)
+		}
+		if decoded := decodeMsgpack(t, data); !reflect.DeepEqual(decoded, value) {
+			t.Errorf("array of %d: decoded differently", test.length)
+		}
+	}
+}
+
+func TestMsgpackMaps(t *testing.T) {
+	tests := []struct {
+		length int
+		marker byte
+	}{
+		{0, 0x80},
+		{15, 0x8f},
+		{16, 0xde},
+		{65536, 0xdf},
+	}
+
+	for _, test := range tests {
+		value := make(map[string]interface{}, test.length)
+		for i := 0; i < test.length; i++ {
+			value[fmt.Sprintf("key%d", i)] = int64(i)
+		}
+		data := encodeMsgpack(t, value)
+
+		if data[0] != test.marker {
+			t.Errorf("map of %d: marker %#x, want %#x", test.length, data[0], test.marker)
+		}
+		if decoded := decodeMsgpack(t, data); !reflect.DeepEqual(decoded, value) {
+			t.Errorf("map of %d: decoded differently", test.length)
+		}
+	}
+}
+
+func TestMsgpackValues(t *testing.T) {
+	values := []interface{}{
+		nil,
+		true,
+		false,
+		1.5,
+		[]byte("binary"),
+		MsgpackExt{Type: 1, Data: []byte{0x05}},
+		MsgpackExt{Type: 2, Data: []byte{0xcd, 0x01, 0x00}},
+		[]interface{}{"nested", []interface{}{int64(1), nil}, map[string]interface{}{"key": "value"}},
+	}
+
+	for _, value := range values {
+		if decoded := decodeMsgpack(t, encodeMsgpack(t, value)); !reflect.DeepEqual(decoded, value) {
+			t.Errorf("%#v: decoded %#v", value, decoded)
+		}
+	}
+
+	// []string is sent as an array of strings
+	decoded := decodeMsgpack(t, encodeMsgpack(t, []string{"a", "b"}))
+	if !reflect.DeepEqual(decoded, []interface{}{"a", "b"}) {
+		t.Errorf("[]string: decoded %#v", decoded)
+	}
+
+	handle, err := MsgpackExt{Type: 0, Data: []byte{0xcd, 0x01, 0x00}}.Handle()
+	if err != nil || handle != 256 {
+		t.Errorf("handle = %d, %v", handle, err)
+	}
+}
+
+func TestMsgpackTruncated(t *testing.T) {
+	data := encodeMsgpack(t, []interface{}{"truncated", int64(1000)})
+	for n := 1; n < len(data); n++ {
+		if _, err := newMsgpackDecoder(bytes.NewReader(data[:n])).Decode(); err == nil {
+			t.Errorf("decoding %d of %d bytes succeeded", n, len(data))
+		}
+	}
+}
+
+func TestMsgpackOversizedLength(t *testing.T) {
+	// length prefixes near 4GB with nothing after them, decoding them must not
+	// try to allocate that much
+	tests := map[string][]byte{
+		"bin 32":   {0xc6, 0xff, 0xff, 0xff, 0xff},
+		"ext 32":   {0xc9, 0xff, 0xff, 0xff, 0xff, 0x01},
+		"str 32":   {0xdb, 0xff, 0xff, 0xff, 0xf0},
+		"array 32": {0xdd, 0xff, 0xff, 0xff, 0xff},
+		"map 32":   {0xdf, 0x80, 0x00, 0x00, 0x00},
+		"nested":   {0x91, 0xdd, 0x7f, 0xff, 0xff, 0xff},
+	}
+
+	for name, data := range tests {
+		_, err := newMsgpackDecoder(bytes.NewReader(data)).Decode()
+		if err == nil || !strings.Contains(err.Error(), "over the limit") {
+			t.Errorf("%s: error = %v, want a length limit error", name, err)
+		}
+	}
+}
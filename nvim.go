@@ -1,12 +1,9 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
-	"os"
-	"os/exec"
-	"regexp"
-	"strings"
-	"text/template"
 )
 
 type NvimMode string
@@ -18,14 +15,11 @@ const (
 	VisualMode  NvimMode = "v"
 )
 
-var getModeCmd = template.Must(template.New("getModeCmd").Parse(`
-	return vim.api.nvim_get_mode()["mode"]
-`))
-
-var getInsertionTextCmd = template.Must(template.New("getInsertionTextCmd").Parse(`
+// the lines around the cursor in insert mode, with sigil inserted at the cursor
+// position. Arguments: number of lines either side, sigil
+const getInsertionTextLua = `
+	local num_lines, sigil = ...
 	local mode = vim.api.nvim_get_mode()["mode"]
-	local num_lines = {{.NumLines}}
-	local sigil = "{{.Sigil}}"
 
 	if mode == "i" then
 		local current_line = vim.api.nvim_win_get_cursor(0)[1]
@@ -61,14 +55,14 @@ var getInsertionTextCmd = template.Must(template.New("getInsertionTextCmd").Pars
 	end
 
 	return "" -- don't want to type anything strange when in another mode
-`))
+`
 
 // get text across all visible buffers
-// contextExtend is the number of lines to include outside the visible buffer
-var getVisibleTextCmd = template.Must(template.New("getVisibleTextCmd").Parse(`
+// Arguments: the number of lines to include outside the visible buffer
+const getVisibleTextLua = `
+	local CONTEXT_EXTEND = ...
 	-- backticks can't be escaped in raw go string literal
 	local three_ticks = string.rep(string.char(96), 3)
-	local CONTEXT_EXTEND = {{.ContextExtend}}
 
 	-- This generates a context string of the currently visible text in the
 	-- specified win_id (or the current window if none is specified)
@@ -106,7 +100,114 @@ var getVisibleTextCmd = template.Must(template.New("getVisibleTextCmd").Parse(`
 	end
 
 	return table.concat(contexts, "\n")
-`))
+`
+
+// insert text at the cursor as a single undo step. In insert mode the text
+// goes at the cursor and the cursor ends up after it, in normal mode it goes
+// after the character under the cursor (like a) and the cursor ends up on the
+// last inserted character. Returns "ok", or the mode if it can't insert.
+// Arguments: text
+const insertTextLua = `
+	local text = ...
+	local mode = vim.api.nvim_get_mode()["mode"]
+	local insert = mode:sub(1, 1) == "i"
+
+	if not insert and mode ~= "n" then
+		return mode
+	end
+
+	local row, col = unpack(vim.api.nvim_win_get_cursor(0))
+	row = row - 1
+
+	if not insert then
+		-- move past the character under the cursor
+		local line = vim.api.nvim_get_current_line()
+		if #line > 0 then
+			col = col + 1
+			while col < #line and line:byte(col + 1) >= 0x80 and line:byte(col + 1) < 0xC0 do
+				col = col + 1
+			end
+		end
+	end
+
+	local lines = vim.split(text, "\n", { plain = true })
+
+	-- close the current undo block so the insertion can be undone on its own
+	vim.cmd("let &undolevels = &undolevels")
+	vim.api.nvim_buf_set_text(0, row, col, row, col, lines)
+
+	local end_row = row + #lines - 1
+	local end_col = #lines[#lines]
+	if #lines == 1 then
+		end_col = col + end_col
+	end
+
+	if not insert then
+		end_col = math.max(0, end_col - 1)
+	end
+
+	vim.api.nvim_win_set_cursor(0, { end_row + 1, end_col })
+	return "ok"
+`
+
+// delete characters before the cursor, the opposite of insertTextCmd. A line
+// break counts as one character. Arguments: number of characters
+const eraseTextLua = `
+	local count = ...
+	local mode = vim.api.nvim_get_mode()["mode"]
+	local insert = mode:sub(1, 1) == "i"
+
+	if not insert and mode ~= "n" then
+		return mode
+	end
+
+	local function get_line(row)
+		return vim.api.nvim_buf_get_lines(0, row, row + 1, true)[1]
+	end
+
+	local row, col = unpack(vim.api.nvim_win_get_cursor(0))
+	row = row - 1
+
+	if not insert then
+		-- the character under the cursor was the last one inserted
+		local line = get_line(row)
+		if #line > 0 then
+			col = col + 1
+			while col < #line and line:byte(col + 1) >= 0x80 and line:byte(col + 1) < 0xC0 do
+				col = col + 1
+			end
+		end
+	end
+
+	local start_row, start_col = row, col
+	while count > 0 do
+		if start_col == 0 then
+			if start_row == 0 then
+				break
+			end
+			start_row = start_row - 1
+			start_col = #get_line(start_row)
+		else
+			-- step back a whole utf-8 character
+			local line = get_line(start_row)
+			start_col = start_col - 1
+			while start_col > 0 and line:byte(start_col + 1) >= 0x80 and line:byte(start_col + 1) < 0xC0 do
+				start_col = start_col - 1
+			end
+		end
+		count = count - 1
+	end
+
+	vim.cmd("let &undolevels = &undolevels")
+	vim.api.nvim_buf_set_text(0, start_row, start_col, row, col, {})
+
+	if not insert then
+		start_col = math.max(0, start_col - 1)
+	end
+
+	vim.api.nvim_win_set_cursor(0, { start_row + 1, start_col })
+	return "ok"
+`
 
 type NvimClient struct {
 	socketFile string
@@ -117,174 +218,149 @@ func NewNvimClient() *NvimClient {
 	return &NvimClient{}
 }
 
-// gets the default location for nvim process remote socket based on the PID
-func findRemoteSocketFile(pid string) (string, error) {
-	uid := os.Getuid()
-	socketFile := fmt.Sprintf("/run/user/%d/nvim.%s.0", uid, pid)
-	if _, err := os.Stat(socketFile); os.IsNotExist(err) {
-		return "", fmt.Errorf("no nvim socket file found for PID %s, socket file: %s", pid, socketFile)
-	}
-	return socketFile, nil
-}
-
-// sets the socket to the active window if it's an nvim instance
+// sets the socket to the nvim running in the active window
 func (client *NvimClient) FindActiveNvim() error {
-	// find the PID of the active window in X
-	cmd := exec.Command("sh", "-c", `xprop -root _NET_ACTIVE_WINDOW | awk '{print $5}' | xargs -I {} xprop -id {} _NET_WM_PID | awk '{print $3}'`)
-	output, err := cmd.Output()
+	discovery, err := discoverActiveNvim()
 	if err != nil {
 		return err
 	}
-	pid := strings.TrimSpace(string(output))
-
-	if pid == "" {
-		return fmt.Errorf("No active window found")
-	}
-
-	var searchPid func(string) (string, error)
-	searchPid = func(currentPid string) (string, error) {
-		socketFile, err := findRemoteSocketFile(currentPid)
-
-		if err == nil {
-			return socketFile, nil
-		}
-
-		cmd := exec.Command("sh", "-c", fmt.Sprintf(`pgrep -P %s`, currentPid))
-		output, err := cmd.Output()
-		if err != nil {
-			return "", err
-		}
-		childPids := strings.Split(strings.TrimSpace(string(output)), "\n")
 
-		for _, childPid := range childPids {
-			socketFile, err = searchPid(childPid)
-			if err == nil {
-				return socketFile, nil
-			}
-		}
-		return "", fmt.Errorf("No nvim process within PID %s", currentPid)
-	}
-
-	socketFile, err := searchPid(pid)
-	if err == nil {
-		client.socketFile = socketFile
-		return nil
-	}
-
-	return fmt.Errorf("No nvim process found as a subprocess of PID %s", pid)
+	client.socketFile = discovery.Address
+	return nil
 }
 
 // find any running nvim server and set the socket file path
 func (client *NvimClient) FindFirstNvim() error {
-	cmd := exec.Command("sh", "-c", `pgrep -u $USER -x nvim`)
-	output, err := cmd.Output()
+	discovery, err := discoverAnyNvim()
 	if err != nil {
 		return err
 	}
-	pids := strings.Split(strings.TrimSpace(string(output)), "\n")
-	if len(pids) == 0 || pids[0] == "" {
-		return fmt.Errorf("no running nvim instance found")
-	}
-
-	for _, pid := range pids {
-		socketFile, err := findRemoteSocketFile(pid)
-		if err == nil {
-			client.socketFile = socketFile
-			return nil
-		}
-	}
 
-	return fmt.Errorf("no valid nvim socket file found")
+	client.socketFile = discovery.Address
+	return nil
 }
 
-func (client *NvimClient) RemoteExecute(command string) (string, error) {
-	socketFile := client.socketFile
-	if socketFile == "" {
-		return "", fmt.Errorf("nvim socket not set")
+// call an nvim API method over the client's socket
+func (client *NvimClient) Call(method string, args ...interface{}) (interface{}, error) {
+	if client.socketFile == "" {
+		return nil, fmt.Errorf("nvim socket not set")
 	}
 
-	fmt.Fprintf(os.Stderr, "nvim.RemoteExecute: using socket file: %s\n", socketFile)
-
-	cmd := exec.Command("nvim", "--server", socketFile, "--remote-expr", command)
-	cmdOutput, err := cmd.CombinedOutput()
+	conn, err := getNvimConn(client.socketFile)
 	if err != nil {
-		return "", fmt.Errorf("command execution failed: %v, output: %s", err, cmdOutput)
+		return nil, err
 	}
 
-	ansiEscape := regexp.MustCompile(`\x1b\[[0-9;]*m`)
-	cleanOutput := ansiEscape.ReplaceAllString(string(cmdOutput), "")
+	ctx, cancel := context.WithTimeout(context.Background(), nvimCallTimeout)
+	defer cancel()
 
-	return cleanOutput, nil
+	return conn.Call(ctx, method, args...)
 }
 
-func (client *NvimClient) RemoteExecuteLua(command string) (string, error) {
-	command = strings.ReplaceAll(command, "'", "''")
-	luaCommand := fmt.Sprintf("luaeval('(function() %s end)()')", command)
-	return client.RemoteExecute(luaCommand)
+// run a chunk of Lua, args are available to it as ... and the returned value
+// is decoded, tables become maps or slices
+func (client *NvimClient) ExecLua(code string, args ...interface{}) (interface{}, error) {
+	if args == nil {
+		args = []interface{}{}
+	}
+	return client.Call("nvim_exec_lua", code, args)
 }
 
-// Returns the text in nvim surrounding the cursor when in insertion mode
-func (client *NvimClient) GetInsertionText(cursorSigil string) (string, error) {
-	var insertionTextCmd strings.Builder
-	err := getInsertionTextCmd.Execute(&insertionTextCmd, map[string]interface{}{
-		"NumLines": 20,
-		"Sigil":    cursorSigil,
-	})
+// run a chunk of Lua and format the result as text, tables are formatted as
+// JSON
+func (client *NvimClient) RemoteExecuteLua(command string) (string, error) {
+	result, err := client.ExecLua(command)
 	if err != nil {
 		return "", err
 	}
 
-	insertionTextOutput, err := client.RemoteExecuteLua(insertionTextCmd.String())
-	if err != nil {
-		return "", err
+	switch v := result.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	default:
+		formatted, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return fmt.Sprint(v), nil
+		}
+		return string(formatted), nil
 	}
-
-	return insertionTextOutput, nil
 }
 
-// Returns all the visible text in the current nvim window
-func (client *NvimClient) GetVisibleText() (string, error) {
-	var visibleTextCmd strings.Builder
-	err := getVisibleTextCmd.Execute(&visibleTextCmd, map[string]interface{}{
-		"ContextExtend": 20,
-	})
-
+// assert that a result from nvim is a string
+func nvimString(value interface{}, err error) (string, error) {
 	if err != nil {
 		return "", err
 	}
 
-	command := visibleTextCmd.String()
-
-	output, err := client.RemoteExecuteLua(command)
-	if err != nil {
-		return "", err
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("Expected string from nvim, got %T", value)
 	}
+	return s, nil
+}
+
+// Returns the text in nvim surrounding the cursor when in insertion mode
+func (client *NvimClient) GetInsertionText(cursorSigil string) (string, error) {
+	return nvimString(client.ExecLua(getInsertionTextLua, 20, cursorSigil))
+}
 
-	return output, nil
+// Returns all the visible text in the current nvim window
+func (client *NvimClient) GetVisibleText() (string, error) {
+	return nvimString(client.ExecLua(getVisibleTextLua, 20))
 }
 
 func (client *NvimClient) GetCurrentMode() (NvimMode, error) {
-	var modeCmd strings.Builder
-	err := getModeCmd.Execute(&modeCmd, nil)
+	result, err := client.Call("nvim_get_mode")
 	if err != nil {
 		return "", err
 	}
 
-	modeOutput, err := client.RemoteExecuteLua(modeCmd.String())
-	if err != nil {
-		return "", err
+	mode, ok := result.(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("Expected map from nvim_get_mode, got %T", result)
 	}
-	return NvimMode(strings.TrimSpace(modeOutput)), nil
+
+	modeName, err := nvimString(mode["mode"], nil)
+	return NvimMode(modeName), err
 }
 
 // Returns the title of the current nvim window
 func (client *NvimClient) GetCurrentTitle() (string, error) {
-	titleOutput, err := client.RemoteExecuteLua(`
-		return vim.fn.expand("%:t")
-	`)
+	return nvimString(client.Call("nvim_call_function", "expand", []interface{}{"%:t"}))
+}
+
+// the mode that nvim is in doesn't accept inserted text
+type NvimModeError struct {
+	Mode NvimMode
+}
+
+func (e *NvimModeError) Error() string {
+	return fmt.Sprintf("Can't insert text in nvim mode: %s", e.Mode)
+}
+
+// run an edit command, they return "ok" or the mode that prevented the edit
+func (client *NvimClient) runEditCommand(code string, args ...interface{}) error {
+	result, err := nvimString(client.ExecLua(code, args...))
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	return strings.TrimSpace(titleOutput), nil
+	if result != "ok" {
+		return &NvimModeError{Mode: NvimMode(result)}
+	}
+
+	return nil
+}
+
+// Inserts text at the cursor in insert or normal mode, without triggering
+// mappings, abbreviations or auto indent. Can be undone in one step
+func (client *NvimClient) InsertText(text string) error {
+	return client.runEditCommand(insertTextLua, text)
+}
+
+// Deletes count characters before the cursor, reverses InsertText
+func (client *NvimClient) EraseText(count int) error {
+	return client.runEditCommand(eraseTextLua, count)
 }
@@ -0,0 +1,442 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// NvimCandidate is a possible nvim server address found during discovery
+type NvimCandidate struct {
+	Address string
+	// where the address came from, eg. "runtime dir" or "--listen"
+	Source string
+	Pid    string
+	// the address accepted connections, Reason says why it was rejected
+	Accepted bool
+	Reason   string
+}
+
+// NvimRegistration is an nvim server address registered by the user through
+// config or the HTTP API
+type NvimRegistration struct {
+	Address string
+	// process ID of a local nvim, used to match it to the focused window.
+	// Registrations without one are only used when the focused window is an
+	// ssh or mosh session
+	Pid          int       `json:",omitempty"`
+	RegisteredAt time.Time `json:",omitempty"`
+}
+
+var nvimRegistry = struct {
+	sync.Mutex
+	sockets []NvimRegistration
+}{}
+
+// register an nvim server address, registering it again marks it as the most
+// recently used
+func registerNvimSocket(address string, pid int) {
+	nvimRegistry.Lock()
+	defer nvimRegistry.Unlock()
+
+	sockets := nvimRegistry.sockets[:0]
+	for _, socket := range nvimRegistry.sockets {
+		if socket.Address != address {
+			sockets = append(sockets, socket)
+		}
+	}
+
+	nvimRegistry.sockets = append(sockets, NvimRegistration{
+		Address:      address,
+		Pid:          pid,
+		RegisteredAt: time.Now(),
+	})
+}
+
+// returns false if the address wasn't registered
+func unregisterNvimSocket(address string) bool {
+	nvimRegistry.Lock()
+	defer nvimRegistry.Unlock()
+
+	for i, socket := range nvimRegistry.sockets {
+		if socket.Address == address {
+			nvimRegistry.sockets = append(nvimRegistry.sockets[:i], nvimRegistry.sockets[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// sockets registered through the HTTP API, most recent first, followed by the
+// ones from config
+func getNvimRegistrations() []NvimRegistration {
+	nvimRegistry.Lock()
+	defer nvimRegistry.Unlock()
+
+	registrations := make([]NvimRegistration, 0, len(nvimRegistry.sockets)+len(config.NvimSockets))
+	for i := len(nvimRegistry.sockets) - 1; i >= 0; i-- {
+		registrations = append(registrations, nvimRegistry.sockets[i])
+	}
+	for _, address := range config.NvimSockets {
+		registrations = append(registrations, NvimRegistration{Address: address})
+	}
+	return registrations
+}
+
+// nvimDiscovery searches for an nvim server, recording every address it tries
+type nvimDiscovery struct {
+	Candidates []NvimCandidate
+	// the first accepted address
+	Address string
+
+	// processes that have already been searched
+	visited map[string]bool
+	// the focused window is running ssh or mosh, so nvim may be on another
+	// machine
+	remote bool
+}
+
+func newNvimDiscovery() *nvimDiscovery {
+	return &nvimDiscovery{visited: make(map[string]bool)}
+}
+
+// find the nvim running in the focused window, including inside tmux, or a
+// registered socket when the window is a remote session
+func discoverActiveNvim() (*nvimDiscovery, error) {
+	d := newNvimDiscovery()
+
+	// find the PID of the active window in X
+	cmd := exec.Command("sh", "-c", `xprop -root _NET_ACTIVE_WINDOW | awk '{print $5}' | xargs -I {} xprop -id {} _NET_WM_PID | awk '{print $3}'`)
+	output, err := cmd.Output()
+	if err != nil {
+		return d, err
+	}
+	pid := strings.TrimSpace(string(output))
+
+	if pid == "" {
+		return d, fmt.Errorf("No active window found")
+	}
+
+	if d.searchProcess(pid, "window") {
+		return d, nil
+	}
+
+	if d.remote && d.searchRegistered(false) {
+		return d, nil
+	}
+
+	return d, fmt.Errorf("No nvim process found as a subprocess of PID %s", pid)
+}
+
+// find any nvim server: from the environment, registered sockets, then every
+// running nvim process
+func discoverAnyNvim() (*nvimDiscovery, error) {
+	d := newNvimDiscovery()
+
+	// set in nvim's :terminal, and by older nvim versions
+	for _, name := range []string{"NVIM", "NVIM_LISTEN_ADDRESS"} {
+		if address := os.Getenv(name); address != "" && d.try(address, "$"+name, "") {
+			return d, nil
+		}
+	}
+
+	if d.searchRegistered(true) {
+		return d, nil
+	}
+
+	output, err := exec.Command("pgrep", "-u", strconv.Itoa(os.Getuid()), "nvim").Output()
+	if err != nil && len(d.Candidates) == 0 {
+		return d, fmt.Errorf("no running nvim instance found")
+	}
+
+	for _, pid := range strings.Fields(string(output)) {
+		if d.searchNvimProcess(pid, "process") {
+			return d, nil
+		}
+	}
+
+	return d, fmt.Errorf("no valid nvim socket file found")
+}
+
+// check if an address accepts connections and record it as a candidate,
+// returns true if it's accepted
+func (d *nvimDiscovery) try(address, source, pid string) bool {
+	if d.tried(address) {
+		return false
+	}
+
+	candidate := NvimCandidate{Address: address, Source: source, Pid: pid}
+
+	if _, err := getNvimConn(address); err != nil {
+		candidate.Reason = err.Error()
+	} else {
+		candidate.Accepted = true
+		candidate.Reason = "connected"
+		d.Address = address
+	}
+
+	d.Candidates = append(d.Candidates, candidate)
+	return candidate.Accepted
+}
+
+func (d *nvimDiscovery) tried(address string) bool {
+	for _, candidate := range d.Candidates {
+		if address != "" && candidate.Address == address {
+			return true
+		}
+	}
+	return false
+}
+
+// reject an address without connecting to it
+func (d *nvimDiscovery) reject(address, source, pid, reason string) {
+	if d.tried(address) {
+		return
+	}
+
+	d.Candidates = append(d.Candidates, NvimCandidate{
+		Address: address,
+		Source:  source,
+		Pid:     pid,
+		Reason:  reason,
+	})
+}
+
+// search a process and all of its children for an nvim server
+func (d *nvimDiscovery) searchProcess(pid string, source string) bool {
+	if d.visited[pid] {
+		return false
+	}
+	d.visited[pid] = true
+
+	name := processName(pid)
+
+	switch {
+	case strings.HasPrefix(name, "nvim"):
+		if d.searchNvimProcess(pid, source) {
+			return true
+		}
+	case strings.HasPrefix(name, "tmux"):
+		if d.searchTmux(pid) {
+			return true
+		}
+	case name == "ssh" || strings.HasPrefix(name, "mosh"):
+		d.remote = true
+	}
+
+	for _, registration := range getNvimRegistrations() {
+		if registration.Pid != 0 && strconv.Itoa(registration.Pid) == pid {
+			if d.try(registration.Address, "registered", pid) {
+				return true
+			}
+		}
+	}
+
+	output, _ := exec.Command("pgrep", "-P", pid).Output()
+	for _, childPid := range strings.Fields(string(output)) {
+		if d.searchProcess(childPid, source) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// try the addresses an nvim process could be listening on
+func (d *nvimDiscovery) searchNvimProcess(pid string, source string) bool {
+	d.visited[pid] = true
+
+	args := processArgs(pid)
+	for i, arg := range args {
+		if arg == "--listen" && i+1 < len(args) {
+			if d.try(args[i+1], source+" --listen", pid) {
+				return true
+			}
+		}
+	}
+
+	env := processEnv(pid)
+
+	// set in nvim's :terminal, and older versions listen on
+	// $NVIM_LISTEN_ADDRESS when it's set
+	for _, name := range []string{"NVIM", "NVIM_LISTEN_ADDRESS"} {
+		if address := env[name]; address != "" && d.try(address, source+" $"+name, pid) {
+			return true
+		}
+	}
+
+	// the default address is nvim.<pid>.0 in stdpath("run"), which is
+	// $XDG_RUNTIME_DIR, or a random directory in $TMPDIR/nvim.<user>
+	runtimeDirs := []string{env["XDG_RUNTIME_DIR"], os.Getenv("XDG_RUNTIME_DIR"), fmt.Sprintf("/run/user/%d", os.Getuid())}
+	socketName := fmt.Sprintf("nvim.%s.0", pid)
+
+	for _, dir := range runtimeDirs {
+		if dir == "" {
+			continue
+		}
+
+		socketFile := filepath.Join(dir, socketName)
+		if _, err := os.Stat(socketFile); err != nil {
+			d.reject(socketFile, source+" runtime dir", pid, "no socket file")
+			continue
+		}
+
+		if d.try(socketFile, source+" runtime dir", pid) {
+			return true
+		}
+	}
+
+	tmpDirs := []string{env["TMPDIR"], os.TempDir()}
+	for _, dir := range tmpDirs {
+		if dir == "" {
+			continue
+		}
+
+		matches, _ := filepath.Glob(filepath.Join(dir, "nvim.*", "*", socketName))
+		for _, socketFile := range matches {
+			if d.try(socketFile, source+" temp dir", pid) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// search the active pane of the session a tmux client is attached to. The
+// panes are children of the tmux server, not the client in the window
+func (d *nvimDiscovery) searchTmux(clientPid string) bool {
+	// pass on the server socket the client was started with
+	var tmuxArgs []string
+	args := processArgs(clientPid)
+	for i, arg := range args {
+		if (arg == "-L" || arg == "-S") && i+1 < len(args) {
+			tmuxArgs = append(tmuxArgs, arg, args[i+1])
+		}
+	}
+
+	tmux := func(args ...string) ([]string, error) {
+		output, err := exec.Command("tmux", append(tmuxArgs, args...)...).Output()
+		if err != nil {
+			return nil, err
+		}
+		return strings.Split(strings.TrimSpace(string(output)), "\n"), nil
+	}
+
+	clients, err := tmux("list-clients", "-F", "#{client_pid} #{session_name}")
+	if err != nil {
+		d.reject("", "tmux", clientPid, fmt.Sprintf("Error listing tmux clients: %v", err))
+		return false
+	}
+
+	var session string
+	for _, line := range clients {
+		if pid, name, ok := strings.Cut(line, " "); ok && pid == clientPid {
+			session = name
+		}
+	}
+
+	if session == "" {
+		d.reject("", "tmux", clientPid, "tmux client not found")
+		return false
+	}
+
+	panes, err := tmux("list-panes", "-s", "-t", session, "-F", "#{window_active}#{pane_active} #{pane_pid}")
+	if err != nil {
+		d.reject("", "tmux", clientPid, fmt.Sprintf("Error listing tmux panes: %v", err))
+		return false
+	}
+
+	for _, line := range panes {
+		if active, pid, ok := strings.Cut(line, " "); ok && active == "11" {
+			return d.searchProcess(pid, "tmux pane")
+		}
+	}
+
+	return false
+}
+
+// try registered sockets, when withPid is false only the ones without a
+// process ID are tried
+func (d *nvimDiscovery) searchRegistered(withPid bool) bool {
+	for _, registration := range getNvimRegistrations() {
+		if registration.Pid != 0 && !withPid {
+			continue
+		}
+
+		pid := ""
+		if registration.Pid != 0 {
+			pid = strconv.Itoa(registration.Pid)
+		}
+
+		if d.try(registration.Address, "registered", pid) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// write a table of every candidate that was tried
+func (d *nvimDiscovery) Report(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RESULT\tSOURCE\tPID\tADDRESS\tREASON")
+
+	for _, candidate := range d.Candidates {
+		result := "rejected"
+		if candidate.Accepted {
+			result = "accepted"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", result, candidate.Source, candidate.Pid, candidate.Address, candidate.Reason)
+	}
+
+	tw.Flush()
+}
+
+// the name of a process, empty if it can't be found
+func processName(pid string) string {
+	if comm, err := os.ReadFile(filepath.Join("/proc", pid, "comm")); err == nil {
+		return strings.TrimSpace(string(comm))
+	}
+
+	output, err := exec.Command("ps", "-o", "comm=", "-p", pid).Output()
+	if err != nil {
+		return ""
+	}
+	return filepath.Base(strings.TrimSpace(string(output)))
+}
+
+// the command line arguments of a process, only available on Linux
+func processArgs(pid string) []string {
+	cmdline, err := os.ReadFile(filepath.Join("/proc", pid, "cmdline"))
+	if err != nil {
+		return nil
+	}
+	return strings.Split(string(bytes.TrimRight(cmdline, "\x00")), "\x00")
+}
+
+// the environment of a process, only available on Linux for processes of the
+// same user
+func processEnv(pid string) map[string]string {
+	env := make(map[string]string)
+
+	environ, err := os.ReadFile(filepath.Join("/proc", pid, "environ"))
+	if err != nil {
+		return env
+	}
+
+	for _, entry := range strings.Split(string(environ), "\x00") {
+		if name, value, ok := strings.Cut(entry, "="); ok {
+			env[name] = value
+		}
+	}
+	return env
+}
@@ -0,0 +1,12 @@
+//go:build !windows
+
+package main
+
+import (
+	"fmt"
+	"net"
+)
+
+func dialNvimPipe(address string) (net.Conn, error) {
+	return nil, fmt.Errorf("Named pipes are only supported on Windows")
+}
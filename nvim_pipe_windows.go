@@ -0,0 +1,14 @@
+package main
+
+import (
+	"net"
+
+	"github.com/Microsoft/go-winio"
+)
+
+// connect to a named pipe like \\.\pipe\nvim.1234.0, the default listen
+// address for nvim on Windows
+func dialNvimPipe(address string) (net.Conn, error) {
+	timeout := nvimCallTimeout
+	return winio.DialPipe(address, &timeout)
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// calls to nvim fail after this long, eg. when it's waiting at a prompt
+const nvimCallTimeout = 3 * time.Second
+
+// msgpack-RPC message types
+const (
+	rpcRequest      = 0
+	rpcResponse     = 1
+	rpcNotification = 2
+)
+
+// NvimError is an error returned by nvim for a call
+type NvimError struct {
+	Type    int64
+	Message string
+}
+
+func (e *NvimError) Error() string {
+	return fmt.Sprintf("nvim: %s", e.Message)
+}
+
+type rpcResult struct {
+	value interface{}
+	err   error
+}
+
+// nvimConn is a msgpack-RPC connection to an nvim instance, safe for concurrent
+// calls
+type nvimConn struct {
+	conn    net.Conn
+	writeMu sync.Mutex
+	encoder *msgpackEncoder
+
+	mu      sync.Mutex
+	nextID  uint32
+	pending map[uint32]chan rpcResult
+	// set once the connection has failed, all later calls return it
+	err error
+}
+
+// connect to an nvim listen address, either a unix socket path, a named pipe
+// on Windows or host:port for TCP
+func dialNvim(address string) (*nvimConn, error) {
+	var conn net.Conn
+	var err error
+
+	if isNamedPipe(address) {
+		conn, err = dialNvimPipe(address)
+	} else if isNvimTCPAddress(address) {
+		conn, err = net.DialTimeout("tcp", address, nvimCallTimeout)
+	} else {
+		conn, err = net.DialTimeout("unix", address, nvimCallTimeout)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("Error connecting to nvim at %s: %v", address, err)
+	}
+
+	return newNvimConn(conn), nil
+}
+
+// host:port, anything else is a path
+func isNvimTCPAddress(address string) bool {
+	return !strings.Contains(address, "/") && !strings.Contains(address, `\`) && strings.Contains(address, ":")
+}
+
+// named pipes look like \\.\pipe\name, or \\server\pipe\name
+func isNamedPipe(address string) bool {
+	parts := strings.SplitN(address, `\`, 5)
+	return len(parts) == 5 && parts[0] == "" && parts[1] == "" && parts[2] != "" && strings.EqualFold(parts[3], "pipe") && parts[4] != ""
+}
+
+// start talking msgpack-RPC over an open connection
+func newNvimConn(conn net.Conn) *nvimConn {
+	c := &nvimConn{
+		conn:    conn,
+		encoder: newMsgpackEncoder(conn),
+		pending: make(map[uint32]chan rpcResult),
+	}
+
+	go c.readLoop()
+	return c
+}
+
+// call an API method, returns the decoded result or the error from nvim
+func (c *nvimConn) Call(ctx context.Context, method string, args ...interface{}) (interface{}, error) {
+	if args == nil {
+		args = []interface{}{}
+	}
+
+	resultCh := make(chan rpcResult, 1)
+
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return nil, c.err
+	}
+	id := c.nextID
+	c.nextID++
+	c.pending[id] = resultCh
+	c.mu.Unlock()
+
+	c.writeMu.Lock()
+	err := c.encoder.Encode([]interface{}{rpcRequest, id, method, args})
+	c.writeMu.Unlock()
+
+	if err != nil {
+		c.fail(fmt.Errorf("Error writing to nvim: %v", err))
+	}
+
+	select {
+	case result := <-resultCh:
+		return result.value, result.err
+	case <-ctx.Done():
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+		return nil, fmt.Errorf("nvim %s: %v", method, ctx.Err())
+	}
+}
+
+// Closed returns true once the connection can no longer be used
+func (c *nvimConn) Closed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err != nil
+}
+
+func (c *nvimConn) Close() error {
+	c.fail(fmt.Errorf("nvim connection closed"))
+	return c.conn.Close()
+}
+
+// mark the connection as failed and fail every pending call
+func (c *nvimConn) fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.err != nil {
+		return
+	}
+
+	c.err = err
+	for id, resultCh := range c.pending {
+		resultCh <- rpcResult{err: err}
+		delete(c.pending, id)
+	}
+	c.conn.Close()
+}
+
+func (c *nvimConn) readLoop() {
+	decoder := newMsgpackDecoder(c.conn)
+
+	for {
+		message, err := decoder.Decode()
+		if err != nil {
+			if err == io.EOF {
+				c.fail(fmt.Errorf("nvim closed the connection"))
+			} else {
+				c.fail(fmt.Errorf("Error reading from nvim: %v", err))
+			}
+			return
+		}
+
+		fields, ok := message.([]interface{})
+		if !ok || len(fields) == 0 {
+			c.fail(fmt.Errorf("Invalid message from nvim: %v", message))
+			return
+		}
+
+		messageType, _ := msgpackInt(fields[0])
+		switch messageType {
+		case rpcResponse:
+			if len(fields) != 4 {
+				c.fail(fmt.Errorf("Invalid response from nvim: %v", message))
+				return
+			}
+			id, _ := msgpackInt(fields[1])
+			c.resolve(uint32(id), fields[2], fields[3])
+		case rpcRequest:
+			// nvim only sends requests to clients that registered methods,
+			// reply so it isn't left waiting
+			if len(fields) == 4 {
+				c.writeMu.Lock()
+				c.encoder.Encode([]interface{}{rpcResponse, fields[1], "Method not supported", nil})
+				c.writeMu.Unlock()
+			}
+		case rpcNotification:
+			// not subscribed to any events
+		}
+	}
+}
+
+func (c *nvimConn) resolve(id uint32, rpcErr interface{}, value interface{}) {
+	c.mu.Lock()
+	resultCh, ok := c.pending[id]
+	delete(c.pending, id)
+	c.mu.Unlock()
+
+	if !ok {
+		// the call timed out
+		return
+	}
+
+	if rpcErr != nil {
+		resultCh <- rpcResult{err: parseNvimError(rpcErr)}
+		return
+	}
+
+	resultCh <- rpcResult{value: value}
+}
+
+// nvim errors are sent as [type, message]
+func parseNvimError(value interface{}) error {
+	if fields, ok := value.([]interface{}); ok && len(fields) == 2 {
+		errorType, _ := msgpackInt(fields[0])
+		if message, ok := fields[1].(string); ok {
+			return &NvimError{Type: errorType, Message: message}
+		}
+	}
+
+	return &NvimError{Message: fmt.Sprint(value)}
+}
+
+// connections are kept open and shared between clients using the same address
+var nvimConnections = struct {
+	sync.Mutex
+	byAddress map[string]*nvimConn
+}{byAddress: make(map[string]*nvimConn)}
+
+// get an open connection to the address, reconnecting if the last one failed
+func getNvimConn(address string) (*nvimConn, error) {
+	nvimConnections.Lock()
+	defer nvimConnections.Unlock()
+
+	if conn, ok := nvimConnections.byAddress[address]; ok && !conn.Closed() {
+		return conn, nil
+	}
+
+	conn, err := dialNvim(address)
+	if err != nil {
+		return nil, err
+	}
+
+	nvimConnections.byAddress[address] = conn
+	return conn, nil
+}
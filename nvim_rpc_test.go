@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// the nvim end of a connection, reads requests and sends responses by hand
+type nvimStandIn struct {
+	t       *testing.T
+	conn    net.Conn
+	decoder *msgpackDecoder
+	encoder *msgpackEncoder
+}
+
+func newNvimStandIn(t *testing.T) (*nvimStandIn, *nvimConn) {
+	serverConn, clientConn := net.Pipe()
+	server := &nvimStandIn{
+		t:       t,
+		conn:    serverConn,
+		decoder: newMsgpackDecoder(serverConn),
+		encoder: newMsgpackEncoder(serverConn),
+	}
+
+	client := newNvimConn(clientConn)
+	t.Cleanup(func() {
+		client.Close()
+		serverConn.Close()
+	})
+	return server, client
+}
+
+// read the next request, returning its id, method and arguments
+func (s *nvimStandIn) readRequest() (int64, string, []interface{}) {
+	s.t.Helper()
+	s.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	message, err := s.decoder.Decode()
+	if err != nil {
+		s.t.Fatalf("reading request: %v", err)
+	}
+
+	fields, _ := message.([]interface{})
+	if len(fields) != 4 || fields[0] != int64(rpcRequest) {
+		s.t.Fatalf("invalid request: %v", message)
+	}
+
+	method, _ := fields[2].(string)
+	args, _ := fields[3].([]interface{})
+	return fields[1].(int64), method, args
+}
+
+func (s *nvimStandIn) send(message ...interface{}) {
+	s.t.Helper()
+	if err := s.encoder.Encode(message); err != nil {
+		s.t.Fatalf("sending %v: %v", message, err)
+	}
+}
+
+type callResult struct {
+	value interface{}
+	err   error
+}
+
+// start a call in the background, the stand-in has to answer it
+func startCall(ctx context.Context, client *nvimConn, method string, args ...interface{}) chan callResult {
+	done := make(chan callResult, 1)
+	go func() {
+		value, err := client.Call(ctx, method, args...)
+		done <- callResult{value, err}
+	}()
+	return done
+}
+
+func waitCall(t *testing.T, done chan callResult) callResult {
+	t.Helper()
+	select {
+	case result := <-done:
+		return result
+	case <-time.After(5 * time.Second):
+		t.Fatal("call didn't return")
+		return callResult{}
+	}
+}
+
+func TestNvimCallRequestIDs(t *testing.T) {
+	server, client := newNvimStandIn(t)
+
+	for expected := int64(0); expected < 3; expected++ {
+		done := startCall(context.Background(), client, "nvim_buf_get_lines", int64(0), int64(-2), int64(-1), false)
+
+		id, method, args := server.readRequest()
+		if id != expected {
+			t.Errorf("request id = %d, want %d", id, expected)
+		}
+		if method != "nvim_buf_get_lines" || !reflect.DeepEqual(args, []interface{}{int64(0), int64(-2), int64(-1), false}) {
+			t.Errorf("request = %s %v", method, args)
+		}
+
+		server.send(int64(rpcResponse), id, nil, []interface{}{"line"})
+		result := waitCall(t, done)
+		if result.err != nil || !reflect.DeepEqual(result.value, []interface{}{"line"}) {
+			t.Errorf("result = %v, %v", result.value, result.err)
+		}
+	}
+
+	// calls without arguments still send an empty array
+	done := startCall(context.Background(), client, "nvim_get_mode")
+	id, _, args := server.readRequest()
+	if args == nil || len(args) != 0 {
+		t.Errorf("args = %#v, want an empty array", args)
+	}
+	server.send(int64(rpcResponse), id, nil, nil)
+	waitCall(t, done)
+}
+
+func TestNvimCallOutOfOrder(t *testing.T) {
+	server, client := newNvimStandIn(t)
+
+	first := startCall(context.Background(), client, "first")
+	firstID, _, _ := server.readRequest()
+	second := startCall(context.Background(), client, "second")
+	secondID, _, _ := server.readRequest()
+
+	// a notification and a request from nvim in between are ignored
+	server.send(int64(rpcNotification), "nvim_buf_lines_event", []interface{}{})
+	server.send(int64(rpcRequest), int64(50), "talkxtyper_method", []interface{}{})
+	server.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	reply, err := server.decoder.Decode()
+	if err != nil || !reflect.DeepEqual(reply, []interface{}{int64(rpcResponse), int64(50), "Method not supported", nil}) {
+		t.Errorf("reply to request = %v, %v", reply, err)
+	}
+
+	server.send(int64(rpcResponse), secondID, nil, "second result")
+	server.send(int64(rpcResponse), firstID, nil, "first result")
+
+	if result := waitCall(t, first); result.value != "first result" {
+		t.Errorf("first call = %v, %v", result.value, result.err)
+	}
+	if result := waitCall(t, second); result.value != "second result" {
+		t.Errorf("second call = %v, %v", result.value, result.err)
+	}
+}
+
+func TestNvimCallError(t *testing.T) {
+	server, client := newNvimStandIn(t)
+
+	done := startCall(context.Background(), client, "nvim_command", "bad")
+	id, _, _ := server.readRequest()
+	server.send(int64(rpcResponse), id, []interface{}{int64(0), "Vim:E492: Not an editor command: bad"}, nil)
+
+	result := waitCall(t, done)
+	var nvimErr *NvimError
+	if !errors.As(result.err, &nvimErr) {
+		t.Fatalf("error = %v, want NvimError", result.err)
+	}
+	if nvimErr.Type != 0 || nvimErr.Message != "Vim:E492: Not an editor command: bad" {
+		t.Errorf("error = %+v", nvimErr)
+	}
+
+	// an error in an unexpected shape is still reported
+	done = startCall(context.Background(), client, "nvim_command", "bad")
+	id, _, _ = server.readRequest()
+	server.send(int64(rpcResponse), id, "failed", nil)
+	if result := waitCall(t, done); !errors.As(result.err, &nvimErr) || nvimErr.Message != "failed" {
+		t.Errorf("error = %v", result.err)
+	}
+
+	if client.Closed() {
+		t.Error("an error response closed the connection")
+	}
+}
+
+func TestNvimCallConnectionClosed(t *testing.T) {
+	server, client := newNvimStandIn(t)
+
+	first := startCall(context.Background(), client, "first")
+	second := startCall(context.Background(), client, "second")
+	server.readRequest()
+	server.readRequest()
+	server.conn.Close()
+
+	firstResult := waitCall(t, first)
+	secondResult := waitCall(t, second)
+	if firstResult.err == nil || secondResult.err == nil {
+		t.Fatalf("pending calls returned %v, %v", firstResult.err, secondResult.err)
+	}
+
+	if !client.Closed() {
+		t.Error("connection not marked as closed")
+	}
+
+	// later calls fail right away with the same error
+	if _, err := client.Call(context.Background(), "third"); err != firstResult.err {
+		t.Errorf("later call = %v, want %v", err, firstResult.err)
+	}
+}
+
+func TestNvimCallInvalidMessage(t *testing.T) {
+	server, client := newNvimStandIn(t)
+
+	done := startCall(context.Background(), client, "first")
+	server.readRequest()
+	server.send(int64(rpcResponse), int64(0))
+
+	if result := waitCall(t, done); result.err == nil {
+		t.Fatal("expected an error for an invalid response")
+	}
+	if !client.Closed() {
+		t.Error("connection not marked as closed")
+	}
+}
+
+func TestNvimCallTimeout(t *testing.T) {
+	server, client := newNvimStandIn(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	done := startCall(ctx, client, "slow")
+	id, _, _ := server.readRequest()
+
+	result := waitCall(t, done)
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) || result.err == nil {
+		t.Fatalf("result = %v, %v", result.value, result.err)
+	}
+
+	client.mu.Lock()
+	pending := len(client.pending)
+	client.mu.Unlock()
+	if pending != 0 {
+		t.Errorf("%d calls still pending", pending)
+	}
+
+	// the late response is dropped and the connection keeps working
+	server.send(int64(rpcResponse), id, nil, "late")
+	done = startCall(context.Background(), client, "fast")
+	id, _, _ = server.readRequest()
+	server.send(int64(rpcResponse), id, nil, "fast result")
+	if result := waitCall(t, done); result.value != "fast result" {
+		t.Errorf("result = %v, %v", result.value, result.err)
+	}
+}
+
+func TestIsNamedPipe(t *testing.T) {
+	tests := map[string]bool{
+		`\\.\pipe\nvim.1234.0`:     true,
+		`\\.\PIPE\nvim`:            true,
+		`\\server\pipe\nvim`:       true,
+		`\\.\pipe\`:                false,
+		`C:\Users\me\nvim.sock`:    false,
+		"/run/user/1000/nvim.1.0":  false,
+		"127.0.0.1:6666":           false,
+		`\\.\mailslot\nvim.1234.0`: false,
+	}
+
+	for address, expected := range tests {
+		if isNamedPipe(address) != expected {
+			t.Errorf("isNamedPipe(%q) = %v, want %v", address, !expected, expected)
+		}
+	}
+}
+
+func TestIsNvimTCPAddress(t *testing.T) {
+	tests := map[string]bool{
+		"127.0.0.1:6666":          true,
+		"example.com:6666":        true,
+		"[::1]:6666":              true,
+		"/run/user/1000/nvim.1.0": false,
+		`\\.\pipe\nvim.1234.0`:    false,
+		`C:\Users\me\nvim.sock`:   false,
+		"nvim.sock":               false,
+	}
+
+	for address, expected := range tests {
+		if isNvimTCPAddress(address) != expected {
+			t.Errorf("isNvimTCPAddress(%q) = %v, want %v", address, !expected, expected)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// OllamaChat repairs transcriptions and describes images with the native
+// Ollama chat API (/api/chat)
+type OllamaChat struct {
+	URL    string
+	Model  string
+	client *http.Client
+}
+
+type ollamaMessage struct {
+	Role    string   `json:"role"`
+	Content string   `json:"content"`
+	Images  []string `json:"images,omitempty"`
+}
+
+type ollamaRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Stream   bool            `json:"stream"`
+}
+
+type ollamaResponse struct {
+	Message ollamaMessage `json:"message"`
+}
+
+func NewOllamaChat(model string) (*OllamaChat, error) {
+	if config.OllamaURL == "" {
+		return nil, fmt.Errorf("OllamaURL is not set")
+	}
+
+	if model == "" {
+		return nil, fmt.Errorf("Ollama model is not set")
+	}
+
+	return &OllamaChat{
+		URL:    strings.TrimSuffix(config.OllamaURL, "/"),
+		Model:  model,
+		client: &http.Client{},
+	}, nil
+}
+
+func (c *OllamaChat) Name() string {
+	return "ollama"
+}
+
+func (c *OllamaChat) chat(ctx context.Context, messages []ollamaMessage) (string, error) {
+	var resp ollamaResponse
+	err := postJSON(ctx, c.client, c.URL+"/api/chat", nil, ollamaRequest{
+		Model:    c.Model,
+		Messages: messages,
+		Stream:   false,
+	}, &resp)
+
+	if err != nil {
+		return "", err
+	}
+
+	if resp.Message.Content == "" {
+		return "", fmt.Errorf("No message content returned")
+	}
+
+	return resp.Message.Content, nil
+}
+
+func (c *OllamaChat) Repair(ctx context.Context, transcribedText string, instructions string) (string, error) {
+	text, err := c.chat(ctx, []ollamaMessage{
+		{Role: "system", Content: fixPrompt},
+		{Role: "user", Content: instructions},
+		{Role: "user", Content: fmt.Sprintf("Transcription: %s", transcribedText)},
+	})
+
+	if err != nil {
+		return "", fmt.Errorf("Error sending transcription fix request: %w", err)
+	}
+
+	return text, nil
+}
+
+func (c *OllamaChat) DescribeImage(ctx context.Context, imagePath string) (string, error) {
+	imageData, err := os.ReadFile(imagePath)
+	if err != nil {
+		return "", fmt.Errorf("Error reading image file: %v", err)
+	}
+
+	text, err := c.chat(ctx, []ollamaMessage{
+		{Role: "system", Content: describeImagePrompt},
+		{
+			Role:    "user",
+			Content: "Describe this screenshot.",
+			Images:  []string{base64.StdEncoding.EncodeToString(imageData)},
+		},
+	})
+
+	if err != nil {
+		return "", fmt.Errorf("Error sending image description request: %w", err)
+	}
+
+	return text, nil
+}
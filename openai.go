@@ -3,75 +3,137 @@ package main
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"log"
+	"net/http"
 	"os"
+	"strings"
 
 	"github.com/sashabaranov/go-openai"
 )
 
-var fixPrompt = `You are an voice-to-text typing program that takes the textual result of an automated transcription and a context from the user's screen and fixes the transcription to be what the user likely intended to type.
-
-You will output only the updated transcription and no other text. Do not output information not spoken in the original transcription.`
-
-// tests:
-// The transcription was generated from spoken words and may contain errors. Please use the text provided to identify and correct any inaccuracies, focusing on misheard words, technical terms, or any context-specific discrepancies.
-
 func getOpenAIClient() (*openai.Client, error) {
 	apiKey := os.Getenv("OPENAI_API_KEY")
 	if apiKey == "" {
 		apiKey = config.OpenAIKey
-		if apiKey == "" {
+		// self-hosted OpenAI compatible servers often don't need a key
+		if apiKey == "" && config.OpenAIBaseURL == "" {
 			return nil, fmt.Errorf("OpenAI API key is not set")
 		}
 	}
-	return openai.NewClient(apiKey), nil
+
+	var clientConfig openai.ClientConfig
+
+	switch config.OpenAIAPIType {
+	case "", "openai":
+		clientConfig = openai.DefaultConfig(apiKey)
+		if config.OpenAIBaseURL != "" {
+			clientConfig.BaseURL = strings.TrimSuffix(config.OpenAIBaseURL, "/")
+		}
+	case "azure":
+		if config.OpenAIBaseURL == "" {
+			return nil, fmt.Errorf("OpenAIBaseURL must be set for the azure API type")
+		}
+		clientConfig = openai.DefaultAzureConfig(apiKey, config.OpenAIBaseURL)
+	default:
+		return nil, fmt.Errorf("Unknown OpenAIAPIType: %s", config.OpenAIAPIType)
+	}
+
+	return openai.NewClientWithConfig(clientConfig), nil
 }
 
-// in my testing the Prompt parameter is not very good at repairing the transcription, so we do a two pass process instead
-func transcribeAudio(ctx context.Context, mp3FilePath string, instructions string) (*TranscriptionResult, error) {
+// OpenAITranscriber uses the OpenAI audio transcription API
+type OpenAITranscriber struct {
+	client *openai.Client
+	model  string
+}
+
+func NewOpenAITranscriber() (*OpenAITranscriber, error) {
 	client, err := getOpenAIClient()
 	if err != nil {
 		return nil, fmt.Errorf("Error initializing OpenAI client: %v", err)
 	}
+	return &OpenAITranscriber{client: client, model: config.TranscriptionModel}, nil
+}
+
+func (t *OpenAITranscriber) Name() string {
+	return "openai"
+}
 
+// the gpt-4o transcription models only return json or text, so they have no
+// segments to ask for
+func openAIModelHasSegments(model string) bool {
+	return !strings.HasPrefix(model, "gpt-4o")
+}
+
+func (t *OpenAITranscriber) Transcribe(ctx context.Context, audioPath string, options TranscriptionOptions) (*TranscriptionResult, error) {
 	// Create a request for transcription
 	req := openai.AudioRequest{
-		FilePath:    mp3FilePath,
-		Model:       "whisper-1",
-		Language:    "en",
-		Temperature: 0.5,
-		// Prompt:      instructions,
+		FilePath:    audioPath,
+		Model:       t.model,
+		Language:    options.Language,
+		Temperature: options.Temperature,
+		Prompt:      options.Prompt,
+		Format:      openai.AudioResponseFormatJSON,
+	}
+
+	// the default json format doesn't include segments
+	if options.Segments && openAIModelHasSegments(t.model) {
+		req.Format = openai.AudioResponseFormatVerboseJSON
 	}
 
 	// Perform the transcription
-	resp, err := client.CreateTranscription(ctx, req)
+	resp, err := t.client.CreateTranscription(ctx, req)
+
+	// some compatible servers reject verbose_json, the text is still worth having
+	var apiErr *openai.APIError
+	if req.Format == openai.AudioResponseFormatVerboseJSON && errors.As(err, &apiErr) && apiErr.HTTPStatusCode == http.StatusBadRequest {
+		log.Printf("Transcription with segments was rejected, trying again without: %v\n", err)
+		req.Format = openai.AudioResponseFormatJSON
+		resp, err = t.client.CreateTranscription(ctx, req)
+	}
+
 	if err != nil {
-		return nil, fmt.Errorf("Error sending transcription request: %v", err)
+		return nil, fmt.Errorf("Error sending transcription request: %w", err)
 	}
 
 	result := NewTranscriptionResult()
-
+	result.Backend = t.Name()
 	result.Original = resp.Text
 
-	if instructions != "" {
-		result.RepairPrompt = instructions
-		fixedText, err := fixTranscription(ctx, resp.Text, instructions)
-		if err != nil {
-			return nil, fmt.Errorf("Error fixing transcription: %v", err)
-		}
-		result.Modified = fixedText
+	for _, segment := range resp.Segments {
+		result.Segments = append(result.Segments, TranscriptionSegment{
+			Start: segment.Start,
+			End:   segment.End,
+			Text:  segment.Text,
+		})
 	}
 
 	return result, nil
 }
 
-func fixTranscription(ctx context.Context, transcribedText string, instructions string) (string, error) {
+// OpenAIChat repairs transcriptions and describes images with the OpenAI chat
+// completions API
+type OpenAIChat struct {
+	client *openai.Client
+	model  string
+}
+
+func NewOpenAIChat(model string) (*OpenAIChat, error) {
 	client, err := getOpenAIClient()
 	if err != nil {
-		return "", fmt.Errorf("Error initializing OpenAI client: %v", err)
+		return nil, fmt.Errorf("Error initializing OpenAI client: %v", err)
 	}
+	return &OpenAIChat{client: client, model: model}, nil
+}
+
+func (c *OpenAIChat) Name() string {
+	return "openai"
+}
 
+func (c *OpenAIChat) Repair(ctx context.Context, transcribedText string, instructions string) (string, error) {
 	var messages = []openai.ChatCompletionMessage{
 		{
 			Role:    "system",
@@ -88,28 +150,26 @@ func fixTranscription(ctx context.Context, transcribedText string, instructions
 	}
 
 	req := openai.ChatCompletionRequest{
-		Model:     "gpt-4o",
+		Model:     c.model,
 		Messages:  messages,
 		MaxTokens: 1024,
 	}
 
 	// log.Printf("ChatCompletion for fixing transcription: %+v\n", req)
 
-	resp, err := client.CreateChatCompletion(ctx, req)
+	resp, err := c.client.CreateChatCompletion(ctx, req)
 	if err != nil {
-		return "", fmt.Errorf("Error sending transcription fix request: %v", err)
+		return "", fmt.Errorf("Error sending transcription fix request: %w", err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("No choices returned for transcription fix request")
 	}
 
 	return resp.Choices[0].Message.Content, nil
 }
 
-func describeImage(ctx context.Context, imagePath string) (string, error) {
-	client, err := getOpenAIClient()
-	if err != nil {
-		return "", fmt.Errorf("Error initializing OpenAI client: %v", err)
-	}
-
-	// Read image file
+func (c *OpenAIChat) DescribeImage(ctx context.Context, imagePath string) (string, error) {
 	imageData, err := ioutil.ReadFile(imagePath)
 	if err != nil {
 		return "", fmt.Errorf("Error reading image file: %v", err)
@@ -134,23 +194,27 @@ func describeImage(ctx context.Context, imagePath string) (string, error) {
 	var messages = []openai.ChatCompletionMessage{
 		openai.ChatCompletionMessage{
 			Role:    "system",
-			Content: "You are a voice to text typing assistant who is collecting text on the user's current screen so that a machine generated transcription can be edited to match any phrases appearing on the screen. Include 1 sentence description of what the user is engaging with. Then list out all relevant keywords/names/words that appear in the provided image so that the transcription may be corrected.",
+			Content: describeImagePrompt,
 		},
 		imageMessage,
 	}
 
 	// Create a request for image description
 	req := openai.ChatCompletionRequest{
-		Model:    "gpt-4o",
+		Model:    c.model,
 		Messages: messages,
 	}
 
 	// log.Printf("ChatCompletion: %+v\n", req)
 
 	// Perform the image description
-	resp, err := client.CreateChatCompletion(ctx, req)
+	resp, err := c.client.CreateChatCompletion(ctx, req)
 	if err != nil {
-		return "", fmt.Errorf("Error sending image description request: %v", err)
+		return "", fmt.Errorf("Error sending image description request: %w", err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("No choices returned for image description request")
 	}
 
 	return resp.Choices[0].Message.Content, nil
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/go-vgo/robotgo"
+)
+
+// the clipboard is restored after this delay when pasting, giving the target
+// application time to read the pasted text
+const pasteRestoreDelay = 250 * time.Millisecond
+
+// commands run by the command output are killed after this long
+const outputCommandTimeout = 30 * time.Second
+
+// OutputSink is where transcriptions are sent once complete
+type OutputSink interface {
+	Name() string
+	Output(text string) error
+}
+
+// Eraser is implemented by outputs that can remove text they already output,
+// needed for streaming mode and undo-last
+type Eraser interface {
+	Erase(count int) error
+}
+
+func getOutputSink(name string) (OutputSink, error) {
+	switch name {
+	case "", "type":
+		return TypeSink{DelayMs: config.TypeDelayMs}, nil
+	case "paste":
+		return PasteSink{Keys: config.PasteKeys}, nil
+	case "nvim":
+		return NvimSink{}, nil
+	case "stdout":
+		return StdoutSink{}, nil
+	case "file":
+		if config.OutputFile == "" {
+			return nil, fmt.Errorf("The file output requires OutputFile to be set")
+		}
+		return FileSink{Path: config.OutputFile}, nil
+	case "command":
+		if config.OutputCommand == "" {
+			return nil, fmt.Errorf("The command output requires OutputCommand to be set")
+		}
+		return CommandSink{Command: config.OutputCommand}, nil
+	default:
+		return nil, fmt.Errorf("Unknown output: %s", name)
+	}
+}
+
+// pick the output for a task: from the task options (set by the hotkey), then
+// the profile of the focused application, then the Output config. Invalid
+// outputs fall back to typing
+func resolveOutputSink(options TaskOptions) OutputSink {
+	name := config.Output
+	if profile := findAppProfile(getActiveApplication()); profile != nil && profile.Output != "" {
+		name = profile.Output
+	}
+	if options.Output != "" {
+		name = options.Output
+	}
+
+	sink, err := getOutputSink(name)
+	if err != nil {
+		log.Printf("%v, typing instead\n", err)
+		return TypeSink{DelayMs: config.TypeDelayMs}
+	}
+
+	return sink
+}
+
+// send text to the named output, logging any errors
+func writeOutput(name string, text string) {
+	sink, err := getOutputSink(name)
+	if err != nil {
+		log.Printf("Error writing output: %v\n", err)
+		return
+	}
+
+	if err := sink.Output(text); err != nil {
+		log.Printf("Error writing output to %s: %v\n", sink.Name(), err)
+	}
+}
+
+// erase characters from the named output, if it supports it
+func eraseOutput(name string, count int) {
+	sink, err := getOutputSink(name)
+	if err != nil {
+		log.Printf("Error erasing output: %v\n", err)
+		return
+	}
+
+	eraser, ok := sink.(Eraser)
+	if !ok {
+		log.Printf("Output %s can't erase text\n", sink.Name())
+		return
+	}
+
+	if err := eraser.Erase(count); err != nil {
+		log.Printf("Error erasing output from %s: %v\n", sink.Name(), err)
+	}
+}
+
+// erase previously output characters and output the replacement
+func applyStreamUpdate(update StreamUpdate) {
+	if update.Delete > 0 {
+		eraseOutput(update.Output, update.Delete)
+	}
+
+	if update.Insert != "" {
+		writeOutput(update.Output, update.Insert)
+	}
+}
+
+func eraseWithBackspace(count int) error {
+	for i := 0; i < count; i++ {
+		if err := robotgo.KeyTap("backspace"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TypeSink types the text with simulated key presses
+type TypeSink struct {
+	// delay between characters
+	DelayMs int
+}
+
+func (s TypeSink) Name() string {
+	return "type"
+}
+
+func (s TypeSink) Output(text string) error {
+	robotgo.TypeStr(text, 0, s.DelayMs)
+	return nil
+}
+
+func (s TypeSink) Erase(count int) error {
+	return eraseWithBackspace(count)
+}
+
+// PasteSink puts the text on the clipboard and presses the paste keys, then
+// puts back what was on the clipboard before. Much faster than typing for
+// long text, and not affected by keyboard layouts
+type PasteSink struct {
+	// key combination to paste, eg. "ctrl+shift+v" for terminals. Defaults to
+	// ctrl+v, or cmd+v on macOS
+	Keys string
+}
+
+func (s PasteSink) Name() string {
+	return "paste"
+}
+
+func (s PasteSink) Output(text string) error {
+	previous, readErr := robotgo.ReadAll()
+
+	if err := robotgo.WriteAll(text); err != nil {
+		return fmt.Errorf("Error writing to clipboard: %v", err)
+	}
+
+	key, modifiers := s.pasteKeys()
+	if err := robotgo.KeyTap(key, modifiers); err != nil {
+		return fmt.Errorf("Error pressing paste keys: %v", err)
+	}
+
+	// only text can be restored, other clipboard contents are lost
+	if readErr == nil {
+		time.Sleep(pasteRestoreDelay)
+		if err := robotgo.WriteAll(previous); err != nil {
+			return fmt.Errorf("Error restoring clipboard: %v", err)
+		}
+	}
+
+	return nil
+}
+
+func (s PasteSink) Erase(count int) error {
+	return eraseWithBackspace(count)
+}
+
+// split the paste combination into the key and modifiers for robotgo.KeyTap
+func (s PasteSink) pasteKeys() (string, []string) {
+	keys := s.Keys
+	if keys == "" {
+		if runtime.GOOS == "darwin" {
+			keys = "cmd+v"
+		} else {
+			keys = "ctrl+v"
+		}
+	}
+
+	parts := strings.Split(strings.ToLower(keys), "+")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+
+	return parts[len(parts)-1], parts[:len(parts)-1]
+}
+
+// NvimSink inserts the text directly into the buffer of the focused nvim
+// through its RPC socket, so it isn't affected by mappings, abbreviations or
+// auto indent, and can be undone in one step. Falls back to typing when the
+// focused window isn't nvim or nvim is in a mode that doesn't take text, like
+// the command line
+type NvimSink struct{}
+
+func (s NvimSink) Name() string {
+	return "nvim"
+}
+
+func (s NvimSink) Output(text string) error {
+	client := NewNvimClient()
+	if err := client.FindActiveNvim(); err != nil {
+		log.Printf("nvim output: %v, typing instead\n", err)
+		return s.fallback().Output(text)
+	}
+
+	err := client.InsertText(text)
+	var modeErr *NvimModeError
+	if errors.As(err, &modeErr) {
+		log.Printf("nvim output: %v, typing instead\n", err)
+		return s.fallback().Output(text)
+	}
+
+	return err
+}
+
+func (s NvimSink) Erase(count int) error {
+	client := NewNvimClient()
+	if err := client.FindActiveNvim(); err != nil {
+		return s.fallback().Erase(count)
+	}
+
+	err := client.EraseText(count)
+	var modeErr *NvimModeError
+	if errors.As(err, &modeErr) {
+		return s.fallback().Erase(count)
+	}
+
+	return err
+}
+
+func (s NvimSink) fallback() TypeSink {
+	return TypeSink{DelayMs: config.TypeDelayMs}
+}
+
+// StdoutSink prints each transcription on its own line
+type StdoutSink struct{}
+
+func (s StdoutSink) Name() string {
+	return "stdout"
+}
+
+func (s StdoutSink) Output(text string) error {
+	_, err := fmt.Println(text)
+	return err
+}
+
+// FileSink appends each transcription to a file on its own line
+type FileSink struct {
+	Path string
+}
+
+func (s FileSink) Name() string {
+	return "file"
+}
+
+func (s FileSink) Output(text string) error {
+	file, err := os.OpenFile(s.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("Error opening output file: %v", err)
+	}
+	defer file.Close()
+
+	if _, err := fmt.Fprintln(file, text); err != nil {
+		return fmt.Errorf("Error writing output file: %v", err)
+	}
+
+	return nil
+}
+
+// CommandSink runs a shell command with the transcription on stdin
+type CommandSink struct {
+	Command string
+}
+
+func (s CommandSink) Name() string {
+	return "command"
+}
+
+func (s CommandSink) Output(text string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), outputCommandTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", s.Command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", s.Command)
+	}
+	cmd.Stdin = strings.NewReader(text)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("Error running output command: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/go-vgo/robotgo"
+)
+
+// AppMatch matches the focused application by case insensitive substrings of
+// the process name and window title, an empty field matches anything
+type AppMatch struct {
+	App   string
+	Title string
+}
+
+// AppProfile overrides settings while a matching application has focus
+type AppProfile struct {
+	AppMatch
+
+	// output to use instead of Output
+	Output string
+}
+
+// the application that has focus
+type ActiveApplication struct {
+	Pid   int
+	Name  string
+	Title string
+}
+
+func getActiveApplication() ActiveApplication {
+	app := ActiveApplication{
+		Pid:   robotgo.GetPid(),
+		Title: robotgo.GetTitle(),
+	}
+
+	if name, err := robotgo.FindName(app.Pid); err == nil {
+		app.Name = name
+	}
+
+	return app
+}
+
+func (m AppMatch) Matches(app ActiveApplication) bool {
+	if m.App != "" && !strings.Contains(strings.ToLower(app.Name), strings.ToLower(m.App)) {
+		return false
+	}
+
+	if m.Title != "" && !strings.Contains(strings.ToLower(app.Title), strings.ToLower(m.Title)) {
+		return false
+	}
+
+	return true
+}
+
+// the first profile in the config that matches app, or nil
+func findAppProfile(app ActiveApplication) *AppProfile {
+	for i := range config.AppProfiles {
+		if config.AppProfiles[i].Matches(app) {
+			return &config.AppProfiles[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+var fixPrompt = `You are an voice-to-text typing program that takes the textual result of an automated transcription and a context from the user's screen and fixes the transcription to be what the user likely intended to type.
+
+You will output only the updated transcription and no other text. Do not output information not spoken in the original transcription.`
+
+// tests:
+// The transcription was generated from spoken words and may contain errors. Please use the text provided to identify and correct any inaccuracies, focusing on misheard words, technical terms, or any context-specific discrepancies.
+
+var describeImagePrompt = "You are a voice to text typing assistant who is collecting text on the user's current screen so that a machine generated transcription can be edited to match any phrases appearing on the screen. Include 1 sentence description of what the user is engaging with. Then list out all relevant keywords/names/words that appear in the provided image so that the transcription may be corrected."
+
+// Repairer fixes up a raw transcription using instructions that contain
+// context about what the user is doing
+type Repairer interface {
+	Name() string
+	Repair(ctx context.Context, transcribedText string, instructions string) (string, error)
+}
+
+// Describer extracts a textual description and keywords from a screenshot
+type Describer interface {
+	Name() string
+	DescribeImage(ctx context.Context, imagePath string) (string, error)
+}
+
+// get the repairer for the provider selected in the config
+func getRepairer() (Repairer, error) {
+	switch config.RepairProvider {
+	case "", "openai":
+		return NewOpenAIChat(config.RepairModel)
+	case "anthropic":
+		return NewAnthropicChat(config.AnthropicModel)
+	case "ollama":
+		return NewOllamaChat(config.OllamaModel)
+	default:
+		return nil, fmt.Errorf("Unknown repair provider: %s", config.RepairProvider)
+	}
+}
+
+// get the describer for the provider selected in the config
+func getDescriber() (Describer, error) {
+	switch config.VisionProvider {
+	case "", "openai":
+		return NewOpenAIChat(config.VisionModel)
+	case "anthropic":
+		// the repair model describes the screen too unless another is set
+		model := config.AnthropicVisionModel
+		if model == "" {
+			model = config.AnthropicModel
+		}
+		return NewAnthropicChat(model)
+	case "ollama":
+		return NewOllamaChat(config.OllamaVisionModel)
+	default:
+		return nil, fmt.Errorf("Unknown vision provider: %s", config.VisionProvider)
+	}
+}
+
+func fixTranscription(ctx context.Context, transcribedText string, instructions string) (string, error) {
+	repairer, err := getRepairer()
+	if err != nil {
+		return "", err
+	}
+
+	var fixedText string
+	err = withRetry(ctx, repairer.Name()+" repair", func() error {
+		var err error
+		fixedText, err = repairer.Repair(ctx, transcribedText, instructions)
+		return err
+	})
+	return fixedText, err
+}
+
+func describeImage(ctx context.Context, imagePath string) (string, error) {
+	describer, err := getDescriber()
+	if err != nil {
+		return "", err
+	}
+	return describer.DescribeImage(ctx, imagePath)
+}
+
+// send a JSON request body and decode the JSON response into out. Non 200
+// responses are returned as an HTTPStatusError that includes the response body
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, body interface{}, out interface{}) error {
+	encodedBody, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("Error encoding request: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(encodedBody))
+	if err != nil {
+		return fmt.Errorf("Error creating request: %v", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("Error sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("Error reading response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return newHTTPStatusError(resp, bytes.TrimSpace(respBody))
+	}
+
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("Error decoding response: %v", err)
+	}
+
+	return nil
+}
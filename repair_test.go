@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// a stand-in API server that records the last request and responds with a
+// fixed body
+type providerStandIn struct {
+	*httptest.Server
+	path     string
+	header   http.Header
+	request  map[string]interface{}
+	response string
+}
+
+func newProviderStandIn(t *testing.T) *providerStandIn {
+	standIn := &providerStandIn{}
+	standIn.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		standIn.path = r.URL.Path
+		standIn.header = r.Header.Clone()
+		standIn.request = nil
+
+		if err := json.NewDecoder(r.Body).Decode(&standIn.request); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, standIn.response)
+	}))
+	t.Cleanup(standIn.Close)
+	return standIn
+}
+
+// the value at a path of map keys and slice indexes in the decoded request
+func (s *providerStandIn) field(path ...interface{}) interface{} {
+	var value interface{} = s.request
+	for _, key := range path {
+		switch key := key.(type) {
+		case string:
+			object, _ := value.(map[string]interface{})
+			value = object[key]
+		case int:
+			list, _ := value.([]interface{})
+			if key >= len(list) {
+				return nil
+			}
+			value = list[key]
+		}
+	}
+	return value
+}
+
+func writeTestImage(t *testing.T) (string, string) {
+	t.Helper()
+	imagePath := filepath.Join(t.TempDir(), "screen.png")
+	if err := os.WriteFile(imagePath, []byte("PNG DATA"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return imagePath, base64.StdEncoding.EncodeToString([]byte("PNG DATA"))
+}
+
+func TestAnthropicChat(t *testing.T) {
+	standIn := newProviderStandIn(t)
+	chat := &AnthropicChat{APIKey: "key", BaseURL: standIn.URL, Model: "claude-test", client: standIn.Client()}
+
+	standIn.response = `{"content": [{"type": "text", "text": "fixed "}, {"type": "text", "text": "text"}]}`
+	text, err := chat.Repair(context.Background(), "fixt text", "instructions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "fixed text" {
+		t.Errorf("text = %q", text)
+	}
+
+	if standIn.path != "/v1/messages" {
+		t.Errorf("path = %s", standIn.path)
+	}
+	if standIn.header.Get("x-api-key") != "key" || standIn.header.Get("anthropic-version") != anthropicAPIVersion {
+		t.Errorf("headers = %v", standIn.header)
+	}
+	if standIn.field("model") != "claude-test" || standIn.field("system") != fixPrompt {
+		t.Errorf("request = %v", standIn.request)
+	}
+	if standIn.field("messages", 0, "content", 0, "text") != "instructions" ||
+		standIn.field("messages", 0, "content", 1, "text") != "Transcription: fixt text" {
+		t.Errorf("messages = %v", standIn.field("messages"))
+	}
+
+	imagePath, encoded := writeTestImage(t)
+	standIn.response = `{"content": [{"type": "text", "text": "a terminal"}]}`
+	text, err = chat.DescribeImage(context.Background(), imagePath)
+	if err != nil || text != "a terminal" {
+		t.Fatalf("text = %q, err = %v", text, err)
+	}
+	if standIn.field("messages", 0, "content", 0, "source", "data") != encoded ||
+		standIn.field("messages", 0, "content", 0, "source", "media_type") != "image/png" {
+		t.Errorf("image = %v", standIn.field("messages", 0, "content", 0))
+	}
+
+	standIn.response = `{"content": []}`
+	if _, err := chat.Repair(context.Background(), "text", "instructions"); err == nil {
+		t.Error("expected an error for empty content")
+	}
+}
+
+func TestAnthropicVisionModel(t *testing.T) {
+	oldConfig := config
+	t.Cleanup(func() { config = oldConfig })
+	config.VisionProvider = "anthropic"
+	config.AnthropicKey = "key"
+	config.AnthropicModel = "claude-repair"
+
+	for _, test := range []struct{ visionModel, expected string }{
+		{"", "claude-repair"},
+		{"claude-vision", "claude-vision"},
+	} {
+		config.AnthropicVisionModel = test.visionModel
+		describer, err := getDescriber()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if model := describer.(*AnthropicChat).Model; model != test.expected {
+			t.Errorf("AnthropicVisionModel %q: model = %q, want %q", test.visionModel, model, test.expected)
+		}
+	}
+}
+
+func TestOllamaChat(t *testing.T) {
+	standIn := newProviderStandIn(t)
+	chat := &OllamaChat{URL: standIn.URL, Model: "llama-test", client: standIn.Client()}
+
+	standIn.response = `{"message": {"role": "assistant", "content": "fixed text"}}`
+	text, err := chat.Repair(context.Background(), "fixt text", "instructions")
+	if err != nil || text != "fixed text" {
+		t.Fatalf("text = %q, err = %v", text, err)
+	}
+
+	if standIn.path != "/api/chat" || standIn.field("model") != "llama-test" || standIn.field("stream") != false {
+		t.Errorf("request to %s = %v", standIn.path, standIn.request)
+	}
+	if standIn.field("messages", 0, "content") != fixPrompt ||
+		standIn.field("messages", 1, "content") != "instructions" ||
+		standIn.field("messages", 2, "content") != "Transcription: fixt text" {
+		t.Errorf("messages = %v", standIn.field("messages"))
+	}
+
+	imagePath, encoded := writeTestImage(t)
+	standIn.response = `{"message": {"role": "assistant", "content": "a terminal"}}`
+	text, err = chat.DescribeImage(context.Background(), imagePath)
+	if err != nil || text != "a terminal" {
+		t.Fatalf("text = %q, err = %v", text, err)
+	}
+	if standIn.field("messages", 1, "images", 0) != encoded {
+		t.Errorf("images = %v", standIn.field("messages", 1, "images"))
+	}
+
+	standIn.response = `{"message": {"role": "assistant", "content": ""}}`
+	if _, err := chat.Repair(context.Background(), "text", "instructions"); err == nil {
+		t.Error("expected an error for empty content")
+	}
+}
+
+func TestOpenAIChat(t *testing.T) {
+	standIn := newProviderStandIn(t)
+	clientConfig := openai.DefaultConfig("key")
+	clientConfig.BaseURL = standIn.URL + "/v1"
+	chat := &OpenAIChat{client: openai.NewClientWithConfig(clientConfig), model: "gpt-test"}
+
+	standIn.response = `{"choices": [{"index": 0, "message": {"role": "assistant", "content": "fixed text"}}]}`
+	text, err := chat.Repair(context.Background(), "fixt text", "instructions")
+	if err != nil || text != "fixed text" {
+		t.Fatalf("text = %q, err = %v", text, err)
+	}
+
+	if standIn.path != "/v1/chat/completions" || standIn.header.Get("Authorization") != "Bearer key" {
+		t.Errorf("request to %s with %v", standIn.path, standIn.header)
+	}
+	if standIn.field("model") != "gpt-test" ||
+		standIn.field("messages", 1, "content") != "instructions" ||
+		standIn.field("messages", 2, "content") != "Transcription: fixt text" {
+		t.Errorf("request = %v", standIn.request)
+	}
+
+	imagePath, encoded := writeTestImage(t)
+	standIn.response = `{"choices": [{"index": 0, "message": {"role": "assistant", "content": "a terminal"}}]}`
+	text, err = chat.DescribeImage(context.Background(), imagePath)
+	if err != nil || text != "a terminal" {
+		t.Fatalf("text = %q, err = %v", text, err)
+	}
+	imageURL, _ := standIn.field("messages", 1, "content", 0, "image_url", "url").(string)
+	if imageURL != "data:image/png;base64,"+encoded {
+		t.Errorf("image url = %q", imageURL)
+	}
+
+	standIn.response = `{"choices": []}`
+	if _, err := chat.Repair(context.Background(), "text", "instructions"); err == nil {
+		t.Error("expected an error for no choices")
+	}
+}
+
+func TestPostJSONStatusError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" || r.Header.Get("X-Test") != "yes" {
+			t.Errorf("headers = %v", r.Header)
+		}
+		w.Header().Set("Retry-After", "7")
+		http.Error(w, `{"error": "overloaded"}`, http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	var out struct{}
+	err := postJSON(context.Background(), server.Client(), server.URL, map[string]string{"X-Test": "yes"}, map[string]string{}, &out)
+
+	statusErr, ok := err.(*HTTPStatusError)
+	if !ok {
+		t.Fatalf("error = %v, want HTTPStatusError", err)
+	}
+	if statusErr.StatusCode != http.StatusTooManyRequests || !strings.Contains(statusErr.Body, "overloaded") || statusErr.RetryAfter.Seconds() != 7 {
+		t.Errorf("status error = %+v", statusErr)
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// RerunOptions says which steps to run again for a history entry
+type RerunOptions struct {
+	// transcribe the recording again with Backend, or TranscriptionBackend
+	// when it's empty
+	Transcribe bool
+	Backend    string
+
+	// repair the transcription again with RepairPrompt, or the entry's repair
+	// prompt when it's empty
+	Repair       bool
+	RepairPrompt string
+}
+
+// run a history entry through transcription and repair again. The result is
+// stored in the history as a new revision, linked to the entry by ParentUUID,
+// so the original is kept for comparison
+func rerunHistoryEntry(ctx context.Context, uuid string, options RerunOptions) (*TranscriptionResult, error) {
+	history := taskManager.History()
+	parent := history.Get(uuid)
+	if parent == nil {
+		return nil, fmt.Errorf("History entry not found: %s", uuid)
+	}
+
+	result, err := runEntryAgain(ctx, parent, options)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := history.Add(result); err != nil {
+		return nil, fmt.Errorf("Error saving history: %v", err)
+	}
+
+	return result, nil
+}
+
+// retry a failed history entry from the stage that failed. On success the
+// result replaces the failed entry in the history
+func retryHistoryEntry(ctx context.Context, uuid string) (*TranscriptionResult, error) {
+	history := taskManager.History()
+	failed := history.Get(uuid)
+	if failed == nil {
+		return nil, fmt.Errorf("History entry not found: %s", uuid)
+	}
+
+	if failed.Error == "" {
+		return nil, fmt.Errorf("Entry %s didn't fail, run it again instead", uuid)
+	}
+
+	options := RerunOptions{
+		Transcribe: failed.FailedStage != StageRepair,
+		Repair:     failed.RepairPrompt != "",
+	}
+
+	// a transcription without a repair prompt has nothing left to do
+	if !options.Transcribe && !options.Repair {
+		options.Transcribe = true
+	}
+
+	result, err := runEntryAgain(ctx, failed, options)
+	if err != nil {
+		return nil, err
+	}
+
+	result.ParentUUID = failed.ParentUUID
+	result.Streamed = failed.Streamed
+
+	if err := history.Add(result); err != nil {
+		return nil, fmt.Errorf("Error saving history: %v", err)
+	}
+
+	if _, err := history.Delete(failed.UUID); err != nil {
+		log.Printf("Error removing failed history entry: %v\n", err)
+	}
+
+	return result, nil
+}
+
+// build a new revision of an entry by running steps again, without saving it
+func runEntryAgain(ctx context.Context, parent *TranscriptionResult, options RerunOptions) (*TranscriptionResult, error) {
+	if !options.Transcribe && !options.Repair {
+		return nil, fmt.Errorf("Nothing to run again, choose transcription or repair")
+	}
+
+	history := taskManager.History()
+
+	result := NewTranscriptionResult()
+	result.ParentUUID = parent.UUID
+	result.Backend = parent.Backend
+	result.Original = parent.Original
+	result.Segments = parent.Segments
+	result.Duration = parent.Duration
+	result.TrimmedDuration = parent.TrimmedDuration
+	result.Output = parent.Output
+
+	// the revision keeps its own copy of the recording so it can be deleted
+	// separately
+	audio, err := history.ReadAudio(parent)
+	if err == nil {
+		result.AudioRecording = audio
+		result.AudioFormat = parent.AudioFormat
+	} else if options.Transcribe {
+		return nil, fmt.Errorf("Error reading recording: %v", err)
+	}
+
+	if options.Transcribe {
+		backend := options.Backend
+		if backend == "" {
+			backend = config.TranscriptionBackend
+		}
+
+		transcriber, err := getTranscriberByName(backend)
+		if err != nil {
+			return nil, err
+		}
+
+		audioPath, err := writeAudioToFile(audio, parent.AudioFormat)
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(audioPath)
+
+		log.Printf("Transcribing %s again with %s\n", parent.UUID, transcriber.Name())
+
+		transcribed, err := transcribeWithRetry(ctx, transcriber, audioPath, defaultTranscriptionOptions())
+		if err != nil {
+			return nil, err
+		}
+
+		result.Backend = transcribed.Backend
+		result.Original = transcribed.Original
+		result.Segments = transcribed.Segments
+	}
+
+	if options.Repair {
+		prompt := options.RepairPrompt
+		if prompt == "" {
+			prompt = parent.RepairPrompt
+			result.ContextSources = parent.ContextSources
+		}
+
+		if prompt == "" {
+			return nil, fmt.Errorf("Entry %s has no repair prompt, give one to repair it", parent.UUID)
+		}
+
+		result, err = repairTranscription(ctx, result, prompt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// write encoded audio to a temporary file with the extension of its format,
+// which transcription backends use to detect the format
+func writeAudioToFile(audio []byte, format string) (string, error) {
+	tempFile, err := os.CreateTemp("", fmt.Sprintf("talkxtyper-%d-*.%s", time.Now().Unix(), format))
+	if err != nil {
+		return "", fmt.Errorf("Error creating temporary file: %v", err)
+	}
+	defer tempFile.Close()
+
+	if _, err := tempFile.Write(audio); err != nil {
+		os.Remove(tempFile.Name())
+		return "", fmt.Errorf("Error writing audio file: %v", err)
+	}
+
+	return tempFile.Name(), nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import "math"
+
+// number of zero crossings of the sinc kernel on each side of the center, more
+// gives a sharper low pass filter at the cost of speed
+const resampleZeroCrossings = 16
+
+// above this many filter phases the kernel is calculated for every output
+// sample instead of being precomputed
+const maxResamplePhases = 1024
+
+// resample converts audio between sample rates using a windowed sinc low pass
+// filter, which removes frequencies above the new Nyquist rate when
+// downsampling
+func resample(samples []int16, fromRate int, toRate int) []int16 {
+	if fromRate == toRate || len(samples) == 0 || fromRate <= 0 || toRate <= 0 {
+		return samples
+	}
+
+	// output sample j is at input position j * down / up
+	divisor := gcd(fromRate, toRate)
+	up := toRate / divisor
+	down := fromRate / divisor
+
+	// cutoff as a fraction of the input Nyquist rate, with a little room for
+	// the transition band
+	cutoff := math.Min(1, float64(toRate)/float64(fromRate)) * 0.95
+	halfWidth := int(math.Ceil(resampleZeroCrossings / cutoff))
+
+	// taps for the input samples around the position, given the fractional
+	// part of the position as phase/up
+	kernel := func(phase int, taps []float64) {
+		frac := float64(phase) / float64(up)
+		var sum float64
+		for k := range taps {
+			x := float64(halfWidth-1-k) + frac
+			taps[k] = cutoff * sinc(cutoff*x) * blackman(x, float64(halfWidth))
+			sum += taps[k]
+		}
+		// normalize for unity gain at DC
+		for k := range taps {
+			taps[k] /= sum
+		}
+	}
+
+	var phases [][]float64
+	if up <= maxResamplePhases {
+		phases = make([][]float64, up)
+		for phase := range phases {
+			phases[phase] = make([]float64, 2*halfWidth)
+			kernel(phase, phases[phase])
+		}
+	}
+
+	outLength := int((int64(len(samples))*int64(up) + int64(down) - 1) / int64(down))
+	out := make([]int16, outLength)
+	taps := make([]float64, 2*halfWidth)
+
+	for j := range out {
+		position := int64(j) * int64(down)
+		n := int(position / int64(up))
+		phase := int(position % int64(up))
+
+		if phases != nil {
+			taps = phases[phase]
+		} else {
+			kernel(phase, taps)
+		}
+
+		var value float64
+		first := n - halfWidth + 1
+		for k, tap := range taps {
+			i := first + k
+			if i < 0 || i >= len(samples) {
+				continue
+			}
+			value += tap * float64(samples[i])
+		}
+
+		out[j] = int16(math.Max(-32768, math.Min(32767, math.Round(value))))
+	}
+
+	return out
+}
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
+
+// blackman window centered on 0 that reaches zero at +/- halfWidth
+func blackman(x float64, halfWidth float64) float64 {
+	if math.Abs(x) >= halfWidth {
+		return 0
+	}
+	t := (x/halfWidth + 1) / 2 // 0 to 1 across the window
+	return 0.42 - 0.5*math.Cos(2*math.Pi*t) + 0.08*math.Cos(4*math.Pi*t)
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// one second of a sine wave at half of full scale
+func sineWave(sampleRate int, freq float64) []int16 {
+	samples := make([]int16, sampleRate)
+	for i := range samples {
+		samples[i] = int16(math.Round(0.5 * 32767 * math.Sin(2*math.Pi*freq*float64(i)/float64(sampleRate))))
+	}
+	return samples
+}
+
+// the amplitude, as a fraction of full scale, and phase of a tone in the
+// samples, ignoring the edges where the filter runs off the input
+func measureTone(samples []int16, sampleRate int, freq float64) (float64, float64) {
+	edge := sampleRate / 10
+	var sinSum, cosSum float64
+	n := 0
+	for i := edge; i < len(samples)-edge; i++ {
+		angle := 2 * math.Pi * freq * float64(i) / float64(sampleRate)
+		sinSum += float64(samples[i]) * math.Sin(angle)
+		cosSum += float64(samples[i]) * math.Cos(angle)
+		n++
+	}
+
+	sinPart := 2 * sinSum / float64(n) / 32767
+	cosPart := 2 * cosSum / float64(n) / 32767
+	return math.Hypot(sinPart, cosPart), math.Atan2(cosPart, sinPart)
+}
+
+func TestResampleSine(t *testing.T) {
+	for _, fromRate := range []int{48000, 44100} {
+		for _, freq := range []float64{100, 1000, 3000, 6000} {
+			out := resample(sineWave(fromRate, freq), fromRate, 16000)
+
+			if len(out) != 16000 {
+				t.Errorf("%d -> 16000: got %d samples, want 16000", fromRate, len(out))
+			}
+
+			amplitude, phase := measureTone(out, 16000, freq)
+			if math.Abs(amplitude-0.5) > 0.005 {
+				t.Errorf("%d -> 16000 at %.0fHz: amplitude %.4f, want 0.5", fromRate, freq, amplitude)
+			}
+			if math.Abs(phase) > 0.01 {
+				t.Errorf("%d -> 16000 at %.0fHz: phase error %.4f radians", fromRate, freq, phase)
+			}
+		}
+	}
+}
+
+func TestResampleLength(t *testing.T) {
+	tests := []struct {
+		length, fromRate, toRate, expected int
+	}{
+		{48000, 48000, 16000, 16000},
+		{44100, 44100, 16000, 16000},
+		{1000, 44100, 16000, 363},
+		{1, 48000, 16000, 1},
+		{16000, 16000, 16000, 16000},
+	}
+
+	for _, test := range tests {
+		out := resample(make([]int16, test.length), test.fromRate, test.toRate)
+		if len(out) != test.expected {
+			t.Errorf("%d samples %d -> %d: got %d, want %d", test.length, test.fromRate, test.toRate, len(out), test.expected)
+		}
+	}
+}
+
+func TestResampleRemovesAliases(t *testing.T) {
+	// tones above the 8kHz Nyquist rate of the output would fold back into
+	// the speech band if they weren't filtered out
+	for _, fromRate := range []int{48000, 44100} {
+		for _, freq := range []float64{9000, 12000, 15000} {
+			out := resample(sineWave(fromRate, freq), fromRate, 16000)
+
+			rms, _ := frameStats(out[1600 : len(out)-1600])
+			// the input sine has an RMS of 0.5/sqrt(2)
+			attenuation := 20 * math.Log10(rms/(0.5/math.Sqrt2))
+			if attenuation > -60 {
+				t.Errorf("%d -> 16000 at %.0fHz: attenuated by %.1f dB, want at least 60 dB", fromRate, freq, -attenuation)
+			}
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// the stages of processing a recording that can fail, stored in the history
+// with failed entries so they can be retried from that stage
+const (
+	StageEncode     = "encode"
+	StageTranscribe = "transcribe"
+	StageRepair     = "repair"
+)
+
+// StageError is an error from one stage of processing a recording, along with
+// the result from the stages before it
+type StageError struct {
+	Stage string
+	Err   error
+	// what was done before the stage failed, nil if nothing
+	Result *TranscriptionResult
+}
+
+func (e *StageError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *StageError) Unwrap() error {
+	return e.Err
+}
+
+// HTTPStatusError is a non 200 response from an API
+type HTTPStatusError struct {
+	StatusCode int
+	Status     string
+	Body       string
+	// how long the server asked to wait before trying again, 0 if it didn't
+	RetryAfter time.Duration
+}
+
+func newHTTPStatusError(resp *http.Response, body []byte) *HTTPStatusError {
+	statusErr := &HTTPStatusError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Body:       string(body),
+	}
+
+	// only the seconds form is used by the APIs we talk to
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		statusErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+
+	return statusErr
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("Server returned %s: %s", e.Status, e.Body)
+}
+
+// whether a status is worth trying again: timeouts, rate limits and server
+// errors
+func transientStatus(statusCode int) bool {
+	return statusCode == http.StatusRequestTimeout ||
+		statusCode == http.StatusTooManyRequests ||
+		statusCode >= 500
+}
+
+// OpenAI sends a 429 when the account is out of credits, waiting won't help
+const quotaErrorCode = "insufficient_quota"
+
+// whether an error from an API request may go away by trying again
+func isTransientError(err error) bool {
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return transientStatus(statusErr.StatusCode) && !strings.Contains(statusErr.Body, quotaErrorCode)
+	}
+
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		if apiErr.Code == quotaErrorCode || apiErr.Type == quotaErrorCode {
+			return false
+		}
+		return transientStatus(apiErr.HTTPStatusCode)
+	}
+
+	var requestErr *openai.RequestError
+	if errors.As(err, &requestErr) {
+		return transientStatus(requestErr.HTTPStatusCode)
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	return errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, syscall.ECONNRESET)
+}
+
+// call fn until it succeeds, retrying transient errors up to RetryAttempts
+// times. The wait starts at RetryDelayMs and doubles after each attempt
+func withRetry(ctx context.Context, what string, fn func() error) error {
+	delay := time.Duration(config.RetryDelayMs) * time.Millisecond
+
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= config.RetryAttempts || ctx.Err() != nil || !isTransientError(err) {
+			return err
+		}
+
+		wait := delay
+		var statusErr *HTTPStatusError
+		if errors.As(err, &statusErr) && statusErr.RetryAfter > wait {
+			wait = statusErr.RetryAfter
+		}
+
+		log.Printf("Error in %s, trying again in %v (%d/%d): %v\n", what, wait, attempt+1, config.RetryAttempts, err)
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return err
+		}
+
+		delay *= 2
+	}
+}
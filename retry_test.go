@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestIsTransientError(t *testing.T) {
+	quotaBody := `{"error": {"message": "You exceeded your current quota", "type": "insufficient_quota", "code": "insufficient_quota"}}`
+
+	tests := []struct {
+		name      string
+		err       error
+		transient bool
+	}{
+		{"408", &HTTPStatusError{StatusCode: http.StatusRequestTimeout}, true},
+		{"429", &HTTPStatusError{StatusCode: http.StatusTooManyRequests}, true},
+		{"500", &HTTPStatusError{StatusCode: http.StatusInternalServerError}, true},
+		{"503", &HTTPStatusError{StatusCode: http.StatusServiceUnavailable}, true},
+		{"400", &HTTPStatusError{StatusCode: http.StatusBadRequest}, false},
+		{"401", &HTTPStatusError{StatusCode: http.StatusUnauthorized}, false},
+		{"404", &HTTPStatusError{StatusCode: http.StatusNotFound}, false},
+		{"429 out of credits", &HTTPStatusError{StatusCode: http.StatusTooManyRequests, Body: quotaBody}, false},
+		{"wrapped 503", fmt.Errorf("Error sending request: %w", &HTTPStatusError{StatusCode: http.StatusServiceUnavailable}), true},
+		{"failed stage", &StageError{Stage: StageRepair, Err: &HTTPStatusError{StatusCode: http.StatusBadGateway}}, true},
+
+		{"openai rate limit", &openai.APIError{HTTPStatusCode: 429, Code: "rate_limit_exceeded", Type: "requests"}, true},
+		{"openai quota code", &openai.APIError{HTTPStatusCode: 429, Code: "insufficient_quota"}, false},
+		{"openai quota type", &openai.APIError{HTTPStatusCode: 429, Type: "insufficient_quota"}, false},
+		{"openai server error", &openai.APIError{HTTPStatusCode: 500, Type: "server_error"}, true},
+		{"openai bad request", &openai.APIError{HTTPStatusCode: 400, Code: "invalid_value"}, false},
+		{"wrapped openai error", fmt.Errorf("Error sending transcription request: %w", &openai.APIError{HTTPStatusCode: 503}), true},
+		{"openai request 502", &openai.RequestError{HTTPStatusCode: 502, Err: errors.New("bad gateway")}, true},
+		{"openai request 403", &openai.RequestError{HTTPStatusCode: 403, Err: errors.New("forbidden")}, false},
+
+		{"timeout", fmt.Errorf("Error sending request: %w", timeoutError{}), true},
+		{"deadline", context.DeadlineExceeded, true},
+		{"canceled", context.Canceled, false},
+		{"unexpected EOF", fmt.Errorf("Error reading response: %w", io.ErrUnexpectedEOF), true},
+		{"EOF", io.EOF, true},
+		{"connection reset", &os.SyscallError{Syscall: "read", Err: syscall.ECONNRESET}, true},
+		{"other", errors.New("Error decoding response"), false},
+	}
+
+	for _, test := range tests {
+		if isTransientError(test.err) != test.transient {
+			t.Errorf("%s: transient = %v, want %v", test.name, !test.transient, test.transient)
+		}
+	}
+}
+
+// set the retry config for a test
+func setRetryConfig(t *testing.T, attempts, delayMs int) {
+	oldConfig := config
+	t.Cleanup(func() { config = oldConfig })
+	config.RetryAttempts = attempts
+	config.RetryDelayMs = delayMs
+}
+
+// a fn for withRetry that returns the errors in order, then succeeds, and
+// records when it was called
+type retryRecorder struct {
+	errs  []error
+	calls []time.Time
+}
+
+func (r *retryRecorder) call() error {
+	r.calls = append(r.calls, time.Now())
+	if len(r.calls) <= len(r.errs) {
+		return r.errs[len(r.calls)-1]
+	}
+	return nil
+}
+
+// the waits between calls
+func (r *retryRecorder) waits() []time.Duration {
+	var waits []time.Duration
+	for i := 1; i < len(r.calls); i++ {
+		waits = append(waits, r.calls[i].Sub(r.calls[i-1]))
+	}
+	return waits
+}
+
+func TestWithRetryBackoff(t *testing.T) {
+	setRetryConfig(t, 3, 20)
+
+	unavailable := &HTTPStatusError{StatusCode: http.StatusServiceUnavailable}
+	recorder := &retryRecorder{errs: []error{unavailable, unavailable, unavailable, unavailable}}
+
+	err := withRetry(context.Background(), "test", recorder.call)
+	if err != unavailable {
+		t.Errorf("error = %v, want the last error", err)
+	}
+	if len(recorder.calls) != 4 {
+		t.Fatalf("called %d times, want 4", len(recorder.calls))
+	}
+
+	// the wait doubles each time
+	for i, wait := range recorder.waits() {
+		expected := 20 * time.Millisecond << i
+		if wait < expected || wait > expected+time.Second {
+			t.Errorf("wait %d = %v, want %v", i, wait, expected)
+		}
+	}
+}
+
+func TestWithRetrySucceeds(t *testing.T) {
+	setRetryConfig(t, 3, 1)
+
+	recorder := &retryRecorder{errs: []error{io.ErrUnexpectedEOF, context.DeadlineExceeded}}
+	if err := withRetry(context.Background(), "test", recorder.call); err != nil {
+		t.Errorf("error = %v", err)
+	}
+	if len(recorder.calls) != 3 {
+		t.Errorf("called %d times, want 3", len(recorder.calls))
+	}
+}
+
+func TestWithRetryPermanentError(t *testing.T) {
+	setRetryConfig(t, 3, 1)
+
+	for _, err := range []error{
+		&HTTPStatusError{StatusCode: http.StatusUnauthorized},
+		&openai.APIError{HTTPStatusCode: 429, Code: "insufficient_quota"},
+	} {
+		recorder := &retryRecorder{errs: []error{err}}
+		if got := withRetry(context.Background(), "test", recorder.call); got != err {
+			t.Errorf("error = %v, want %v", got, err)
+		}
+		if len(recorder.calls) != 1 {
+			t.Errorf("%v: called %d times, want 1", err, len(recorder.calls))
+		}
+	}
+
+	// retrying disabled
+	setRetryConfig(t, 0, 1)
+	recorder := &retryRecorder{errs: []error{io.ErrUnexpectedEOF}}
+	withRetry(context.Background(), "test", recorder.call)
+	if len(recorder.calls) != 1 {
+		t.Errorf("called %d times with retrying disabled, want 1", len(recorder.calls))
+	}
+}
+
+func TestWithRetryAfter(t *testing.T) {
+	setRetryConfig(t, 2, 1)
+
+	// a longer Retry-After is waited for, a shorter one doesn't cut the delay
+	rateLimited := &HTTPStatusError{StatusCode: http.StatusTooManyRequests, RetryAfter: 100 * time.Millisecond}
+	recorder := &retryRecorder{errs: []error{rateLimited}}
+	if err := withRetry(context.Background(), "test", recorder.call); err != nil {
+		t.Fatal(err)
+	}
+	if waits := recorder.waits(); len(waits) != 1 || waits[0] < 100*time.Millisecond {
+		t.Errorf("waits = %v, want at least 100ms", waits)
+	}
+
+	setRetryConfig(t, 2, 100)
+	rateLimited = &HTTPStatusError{StatusCode: http.StatusTooManyRequests, RetryAfter: time.Millisecond}
+	recorder = &retryRecorder{errs: []error{rateLimited}}
+	withRetry(context.Background(), "test", recorder.call)
+	if waits := recorder.waits(); len(waits) != 1 || waits[0] < 100*time.Millisecond {
+		t.Errorf("waits = %v, want at least 100ms", waits)
+	}
+}
+
+func TestWithRetryCanceled(t *testing.T) {
+	setRetryConfig(t, 3, 60000)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	recorder := &retryRecorder{errs: []error{io.ErrUnexpectedEOF}}
+	start := time.Now()
+	if err := withRetry(ctx, "test", recorder.call); err != io.ErrUnexpectedEOF {
+		t.Errorf("error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("took %v to stop after the context ended", elapsed)
+	}
+	if len(recorder.calls) != 1 {
+		t.Errorf("called %d times, want 1", len(recorder.calls))
+	}
+}
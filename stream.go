@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// segments shorter than this after trimming are not worth transcribing
+const minStreamSegment = 300 * time.Millisecond
+
+// StreamUpdate is an edit to the text that has already been output by a
+// streaming task: remove Delete characters before the cursor, then insert
+// Insert
+type StreamUpdate struct {
+	Delete int
+	Insert string
+	// name of the output sink the task writes to
+	Output string
+}
+
+// transcribe each segment of audio, recorded at recordRate, as it arrives,
+// passing the text to onUpdate right away. Returns the combined text once
+// segmentCh is closed, and whether any segment failed to transcribe
+func streamTranscription(ctx context.Context, transcriber Transcriber, segmentCh <-chan []int16, recordRate int, onUpdate func(StreamUpdate)) (string, bool) {
+	var text strings.Builder
+	failed := false
+	sampleRate := uploadSampleRate(recordRate)
+
+	// only the text of a segment is used
+	options := defaultTranscriptionOptions()
+	options.Segments = false
+
+	for segment := range segmentCh {
+		if failed || ctx.Err() != nil {
+			// keep draining so the recorder never blocks
+			continue
+		}
+
+		segment = resample(segment, recordRate, sampleRate)
+
+		if config.TrimThreshold > 0 {
+			segment = trimSilence(segment, sampleRate, config.TrimThreshold, time.Duration(config.TrimPaddingMs)*time.Millisecond)
+		}
+
+		if samplesDuration(segment, sampleRate) < minStreamSegment {
+			continue
+		}
+
+		segmentPath, err := writeRecordingToFile(segment, sampleRate)
+		if err != nil {
+			log.Printf("Error writing audio file for segment: %v\n", err)
+			failed = true
+			continue
+		}
+
+		result, err := transcriber.Transcribe(ctx, segmentPath, options)
+		os.Remove(segmentPath)
+
+		if err != nil {
+			log.Printf("Error transcribing segment: %v\n", err)
+			failed = true
+			continue
+		}
+
+		segmentText := strings.TrimSpace(result.Original)
+		if segmentText == "" {
+			continue
+		}
+
+		if text.Len() > 0 {
+			segmentText = " " + segmentText
+		}
+
+		text.WriteString(segmentText)
+		onUpdate(StreamUpdate{Insert: segmentText})
+	}
+
+	return text.String(), failed
+}
+
+// calculate the edit that turns text that was already output into the final
+// text, keeping the common prefix. Returns nil if nothing needs to change
+func streamCorrection(output string, final string) *StreamUpdate {
+	outputRunes := []rune(output)
+	finalRunes := []rune(final)
+
+	prefix := 0
+	for prefix < len(outputRunes) && prefix < len(finalRunes) && outputRunes[prefix] == finalRunes[prefix] {
+		prefix++
+	}
+
+	if prefix == len(outputRunes) && prefix == len(finalRunes) {
+		return nil
+	}
+
+	return &StreamUpdate{
+		Delete: len(outputRunes) - prefix,
+		Insert: string(finalRunes[prefix:]),
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import "testing"
+
+func TestStreamFinalEdit(t *testing.T) {
+	final := &TranscriptionResult{Original: "hello there", Modified: "Hello there."}
+
+	tests := []struct {
+		name     string
+		streamed streamOutput
+		final    *TranscriptionResult
+		edit     *StreamUpdate
+	}{
+		{"unchanged", streamOutput{text: "Hello there."}, final, nil},
+		{"repaired", streamOutput{text: "Hello there"}, final, &StreamUpdate{Insert: "."}},
+		{"corrected", streamOutput{text: "Hello thar"}, final, &StreamUpdate{Delete: 2, Insert: "ere."}},
+		{"segment failed", streamOutput{text: "Hello", failed: true}, final, &StreamUpdate{Insert: " there."}},
+		// the entry is marked Streamed, so a retry won't type it again
+		{"repair failed", streamOutput{text: "hello there"}, nil, nil},
+		// a retry types the whole transcription, so the partial text goes
+		{"segment and fallback failed", streamOutput{text: "héllo", failed: true}, nil, &StreamUpdate{Delete: 5}},
+		{"nothing streamed", streamOutput{failed: true}, nil, nil},
+	}
+
+	for _, test := range tests {
+		edit := test.streamed.finalEdit(test.final)
+		if (edit == nil) != (test.edit == nil) || edit != nil && *edit != *test.edit {
+			t.Errorf("%s: edit = %+v, want %+v", test.name, edit, test.edit)
+		}
+	}
+}
@@ -1,27 +1,64 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type TranscriptionResult struct {
-	UUID         string
+	UUID      string
+	CreatedAt time.Time
+	// the history entry this was made from by running it again
+	ParentUUID   string `json:",omitempty"`
+	Backend      string
 	Original     string
 	Modified     string
 	RepairPrompt string
-	Mp3Recording []byte `json:"-"`
+	// names of the context providers included in the repair prompt
+	ContextSources []string `json:",omitempty"`
+	// length of the recording in seconds, and the length that was uploaded
+	// after trimming silence
+	Duration        float64
+	TrimmedDuration float64
+	Segments        []TranscriptionSegment `json:",omitempty"`
+	// the text was already output while recording
+	Streamed bool
+	// name of the output sink the text is written to
+	Output string
+	// problem noticed with the recording, like clipped audio
+	Warning string `json:",omitempty"`
+	// set when processing the recording failed, with the stage that failed.
+	// The entry is kept in the history so the recording can be retried
+	Error       string `json:",omitempty"`
+	FailedStage string `json:",omitempty"`
+	// the encoded recording, and the format it was encoded in
+	AudioRecording []byte `json:"-"`
+	AudioFormat    string
+}
+
+// a timestamped span of the transcription, in seconds from the start of the
+// recording. Only available when the backend returns them
+type TranscriptionSegment struct {
+	Start float64
+	End   float64
+	Text  string
 }
 
 func NewTranscriptionResult() *TranscriptionResult {
 	return &TranscriptionResult{
-		UUID: uuid.New().String(),
+		UUID:      uuid.New().String(),
+		CreatedAt: time.Now(),
 	}
 }
 
@@ -32,22 +69,59 @@ func (tr *TranscriptionResult) String() string {
 	return tr.Original
 }
 
+// result of the streaming transcription goroutine
+type streamOutput struct {
+	text   string
+	failed bool
+}
+
+// the edit that leaves the streamed output matching the final transcription,
+// nil if nothing needs to change. final is nil when processing failed: text
+// from a complete stream stays, since its history entry is marked Streamed, but
+// the partial text from a stream with a failed segment is erased so a retry
+// can type the whole transcription
+func (s streamOutput) finalEdit(final *TranscriptionResult) *StreamUpdate {
+	if final != nil {
+		return streamCorrection(s.text, final.String())
+	}
+	if s.failed {
+		return streamCorrection(s.text, "")
+	}
+	return nil
+}
+
+// TaskOptions change how a single task runs, overriding the config
+type TaskOptions struct {
+	// skip gathering context and repairing the transcription
+	Raw bool
+	// describe the screen even if IncludeScreen is off
+	ForceScreen bool
+	// name of the output sink, overrides the config and app profiles
+	Output string
+}
+
 // NOTE: all methods for this type should be thread safe
 type TranscribeTask struct {
 	stopRecordingCh   chan struct{}
 	waitForCompletion chan struct{}
 	ctx               context.Context
 	cancel            context.CancelFunc
+	options           TaskOptions
+	output            OutputSink
 	result            *TranscriptionResult
+	stopReason        StopReason
+	warning           string
+	failedUUID        string
 	mu                sync.Mutex
 }
 
 // TODO: this should take a context
-func NewTranscribeTask() *TranscribeTask {
+func NewTranscribeTask(options TaskOptions) *TranscribeTask {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &TranscribeTask{
-		ctx:    ctx,
-		cancel: cancel,
+		ctx:     ctx,
+		cancel:  cancel,
+		options: options,
 	}
 }
 
@@ -78,126 +152,290 @@ func (t *TranscribeTask) SetResult(result *TranscriptionResult) {
 	t.result = result
 }
 
+// why the recording was stopped, empty if still recording
+func (t *TranscribeTask) GetStopReason() StopReason {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.stopReason
+}
+
+func (t *TranscribeTask) setStopReason(reason StopReason) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stopReason = reason
+}
+
+// problem with the recording that the user should know about, empty if none
+func (t *TranscribeTask) GetWarning() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.warning
+}
+
+func (t *TranscribeTask) setWarning(warning string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.warning = warning
+}
+
+// the history entry the recording was saved to when processing it failed,
+// empty if it didn't fail
+func (t *TranscribeTask) GetFailedUUID() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.failedUUID
+}
+
+func (t *TranscribeTask) setFailedUUID(uuid string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.failedUUID = uuid
+}
+
+// send an edit of the streamed output to the task's output
+func (t *TranscribeTask) sendStreamUpdate(update StreamUpdate) {
+	update.Output = t.output.Name()
+	taskManager.SendStreamUpdate(update)
+}
+
 // TODO: this is designed to only be called once, but consider thread safety
 func (t *TranscribeTask) Start() chan TaskState {
 	t.stopRecordingCh = make(chan struct{})
 	t.waitForCompletion = make(chan struct{})
 	stateCh := make(chan TaskState)
 
+	// picked now so the output goes where the user was when they started
+	t.output = resolveOutputSink(t.options)
+
+	// streamed text is corrected by erasing it, which not all outputs can do
+	_, canErase := t.output.(Eraser)
+	streaming := config.StreamingMode && canErase
+	if config.StreamingMode && !canErase {
+		log.Printf("Output %s can't be used for streaming, streaming disabled\n", t.output.Name())
+	}
+
 	go func() {
 		defer close(t.waitForCompletion)
 		defer close(stateCh)
 
-		stateCh <- TaskStateRecording
+		if streaming {
+			stateCh <- TaskStateStreaming
+		} else {
+			stateCh <- TaskStateRecording
+		}
 
-		descriptionCh := make(chan string, 1)
+		// context is gathered while recording so it reflects what the user was
+		// doing when they started speaking
+		type gatheredContext struct {
+			description string
+			sources     []string
+		}
 
-		if config.IncludeScreen {
-			go func() {
-				defer close(descriptionCh)
-				description, err := describeScreen(t.ctx)
-				if err != nil {
-					log.Printf("Error describing screen: %v\n", err)
-					return
-				}
-				log.Printf("Screen Description: %s\n", description)
-
-				description = fmt.Sprintf(description, "\nPlease use the information about the user's screen to aid to transcribing the audio")
-				descriptionCh <- description
-			}()
-		} else if config.IncludeNvim {
-			go func() {
-				defer close(descriptionCh)
-				nvimClient := NewNvimClient()
-				if err := nvimClient.FindActiveNvim(); err != nil {
-					log.Printf("nivm: %v", err)
-					return
+		descriptionCh := make(chan gatheredContext, 1)
+		go func() {
+			description, sources := gatherContext(t.ctx, enabledContextProviders(t.options))
+			descriptionCh <- gatheredContext{description, sources}
+		}()
+
+		// picked before streaming starts so segments are resampled from the
+		// rate the recording is actually made at
+		capture := taskManager.GetCapture()
+		recordRate := recordingSampleRate(capture)
+
+		// in streaming mode segments are transcribed and output while recording
+		var segmentCh chan []int16
+		var streamTranscriber Transcriber
+		streamDone := make(chan streamOutput, 1)
+
+		if streaming {
+			var err error
+			streamTranscriber, err = getTranscriber()
+			if err != nil {
+				// keep recording, transcribing it afterwards reports the error
+				// and saves the recording to the history to retry
+				log.Printf("Error starting streaming transcription, recording without it: %v\n", err)
+				t.setWarning(fmt.Sprintf("Streaming is unavailable, recording without it: %v", err))
+			} else {
+				segmentCh = make(chan []int16, 32)
+				go func() {
+					text, failed := streamTranscription(t.ctx, streamTranscriber, segmentCh, recordRate, t.sendStreamUpdate)
+					streamDone <- streamOutput{text, failed}
+				}()
+			}
+		}
+
+		// remove anything that was already output when the task fails
+		discardStreamed := func() {
+			if segmentCh != nil {
+				if streamed := <-streamDone; streamed.text != "" {
+					t.sendStreamUpdate(StreamUpdate{Delete: len([]rune(streamed.text))})
 				}
+			}
+		}
 
-				log.Printf("Using nvim socket: %s", nvimClient.socketFile)
+		recording, err := recordAudio(t.ctx, capture, t.stopRecordingCh, segmentCh)
+		if err != nil {
+			if !errors.Is(err, errRecordingTooShort) {
+				log.Printf("%v\n", err)
+			}
+			discardStreamed()
+			return
+		}
 
-				var visibleText string
-				var err error
+		t.setStopReason(recording.StopReason)
 
-				currentMode, err := nvimClient.GetCurrentMode()
-				if err != nil {
-					log.Printf("Error getting current nvim mode: %v", err)
-					return
-				}
+		level := analyzeRecording(recording.Samples, recording.SampleRate)
+		if level.Silent() {
+			t.setWarning("Recording was silent, check that the microphone isn't muted and InputDevice is correct")
+			log.Printf("Warning: recording was silent (peak %.4f), skipping transcription\n", level.Peak)
+			discardStreamed()
+			return
+		}
 
-				switch currentMode {
-				case InsertMode:
-					insertionText, err := nvimClient.GetInsertionText("{{CURSOR}}")
-
-					if err != nil {
-						log.Printf("Error getting insertion text: %v", err)
-						return
-					}
-
-					log.Printf("Inserting nvim context: %s", insertionText)
-					descriptionCh <- fmt.Sprintf(
-						"The user is inserting into a text editor with the following content. The cursor is located at {{CURSOR}}:\n%s",
-						insertionText,
-					)
-
-				case NormalMode, VisualMode, CommandMode:
-					visibleText, err = nvimClient.GetVisibleText()
-					if err != nil {
-						log.Printf("Error getting visible text: %v", err)
-						return
-					}
-
-					log.Printf("Visible nvim context: %s", visibleText)
-					descriptionCh <- fmt.Sprintf(
-						"The user is in a text editor with the following content:\n%s",
-						visibleText,
-					)
-
-				default:
-					log.Printf("Unhandled nvim mode, skipping description: %s", currentMode)
-					return
-				}
+		if level.HeavilyClipped() {
+			t.setWarning(fmt.Sprintf("Recording was clipped (%.1f%% of samples at full scale), lower the input gain", level.ClippedFraction*100))
+			log.Printf("Warning: %s\n", t.GetWarning())
+		}
 
-			}()
-		} else {
-			close(descriptionCh)
+		sampleRate := uploadSampleRate(recording.SampleRate)
+		samples := resample(recording.Samples, recording.SampleRate, sampleRate)
+
+		if config.TrimThreshold > 0 {
+			samples = trimSilence(samples, sampleRate, config.TrimThreshold, time.Duration(config.TrimPaddingMs)*time.Millisecond)
+			log.Printf("Trimmed recording from %v to %v\n",
+				samplesDuration(recording.Samples, recording.SampleRate), samplesDuration(samples, sampleRate))
 		}
 
-		recordingBuffer, err := recordAudio(t.ctx, t.stopRecordingCh)
-		if err != nil {
-			log.Printf("%v\n", err)
-			return
+		// details of the recording, saved with the result whether processing
+		// succeeded or not
+		var gathered gatheredContext
+		addRecording := func(result *TranscriptionResult, audio []byte, format string) {
+			result.ContextSources = gathered.sources
+			result.Warning = t.GetWarning()
+			result.Output = t.output.Name()
+			result.Duration = samplesDuration(recording.Samples, recording.SampleRate).Seconds()
+			result.TrimmedDuration = samplesDuration(samples, sampleRate).Seconds()
+			result.AudioRecording = audio
+			result.AudioFormat = format
 		}
 
-		mp3Path, err := writeRecordingToMP3(recordingBuffer)
+		audioPath, err := writeRecordingToFile(samples, sampleRate)
 		if err != nil {
-			log.Printf("Error writing MP3 file: %v\n", err)
+			log.Printf("Error writing audio file: %v\n", err)
+			discardStreamed()
+
+			if t.ctx.Err() != nil {
+				return
+			}
+
+			gathered = <-descriptionCh
+
+			failed := NewTranscriptionResult()
+			failed.Error = err.Error()
+			failed.FailedStage = StageEncode
+			failed.RepairPrompt = gathered.description
+
+			// fall back to WAV, which only needs memory, so the recording can
+			// still be retried from the history
+			var wav bytes.Buffer
+			if err := (WAVEncoder{}).Encode(&wav, samples, sampleRate); err == nil {
+				addRecording(failed, wav.Bytes(), WAVEncoder{}.Format())
+			} else {
+				addRecording(failed, nil, "")
+			}
+
+			t.setWarning(fmt.Sprintf("Encoding the recording failed, it was saved to the history to retry: %v", err))
+			taskManager.AppendToHistory(failed)
+			t.setFailedUUID(failed.UUID)
 			return
 		}
-		defer os.Remove(mp3Path)
+		defer os.Remove(audioPath)
+
+		audioData, readErr := os.ReadFile(audioPath)
+		log.Printf("Audio data size: %d bytes, audio path: %s, error: %v\n", len(audioData), audioPath, readErr)
+		audioFormat := ""
+		if readErr == nil {
+			audioFormat = strings.TrimPrefix(filepath.Ext(audioPath), ".")
+		}
 
 		stateCh <- TaskStateTranscribing
 
-		log.Println("Audio ready, waiting for description")
-		var description string
-		for d := range descriptionCh {
-			description = d
+		log.Println("Audio ready, waiting for context")
+		gathered = <-descriptionCh
+		description := gathered.description
+
+		var transcription *TranscriptionResult
+		var streamed streamOutput
+		chunkLength := time.Duration(config.ChunkSeconds+config.ChunkOverlapSeconds) * time.Second
+
+		if segmentCh != nil {
+			streamed = <-streamDone
 		}
 
-		transcription, err := transcribeAudio(t.ctx, mp3Path, description)
+		if segmentCh != nil && !streamed.failed {
+			transcription = NewTranscriptionResult()
+			transcription.Backend = streamTranscriber.Name()
+			transcription.Original = streamed.text
+			transcription, err = repairTranscription(t.ctx, transcription, description)
+		} else if config.ChunkSeconds > 0 && samplesDuration(samples, sampleRate) > chunkLength {
+			transcription, err = transcribeChunked(t.ctx, samples, sampleRate, description)
+		} else {
+			transcription, err = transcribeAudio(t.ctx, audioPath, description)
+		}
 
 		if err != nil {
 			log.Printf("Error transcribing audio: %v\n", err)
+
+			if segmentCh != nil {
+				if edit := streamed.finalEdit(nil); edit != nil {
+					t.sendStreamUpdate(*edit)
+				}
+			}
+
+			// an aborted task was meant to be thrown away
+			if t.ctx.Err() != nil {
+				return
+			}
+
+			// keep the recording, and anything that was done before the
+			// failure, so it can be retried from the history
+			failed := NewTranscriptionResult()
+			stage := StageTranscribe
+
+			var stageErr *StageError
+			if errors.As(err, &stageErr) {
+				stage = stageErr.Stage
+				if stageErr.Result != nil {
+					failed = stageErr.Result
+				}
+			}
+
+			failed.Error = err.Error()
+			failed.FailedStage = stage
+			// the streamed text was already output before the repair failed
+			failed.Streamed = segmentCh != nil && !streamed.failed
+			if failed.RepairPrompt == "" {
+				failed.RepairPrompt = description
+			}
+			addRecording(failed, audioData, audioFormat)
+
+			t.setWarning(fmt.Sprintf("Transcription failed, the recording was saved to the history to retry: %v", err))
+			taskManager.AppendToHistory(failed)
+			t.setFailedUUID(failed.UUID)
 			return
 		}
 
-		mp3Data, err := os.ReadFile(mp3Path)
-		log.Printf("MP3 data size: %d bytes, MP3 path: %s, error: %v\n", len(mp3Data), mp3Path, err)
-
-		if err == nil {
-			transcription.Mp3Recording = mp3Data
+		if segmentCh != nil {
+			// make the streamed output match the final text in case a segment
+			// failed or the repair changed it
+			transcription.Streamed = true
+			if edit := streamed.finalEdit(transcription); edit != nil {
+				t.sendStreamUpdate(*edit)
+			}
 		}
 
+		addRecording(transcription, audioData, audioFormat)
+
 		transcriptionJSON, err := json.Marshal(transcription)
 		if err == nil {
 			log.Printf("Transcription: %s\n", transcriptionJSON)
@@ -1,6 +1,13 @@
 package main
 
-import "sync/atomic"
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
 
 type TaskState int
 
@@ -8,17 +15,57 @@ const (
 	TaskStateIdle TaskState = iota
 	TaskStateRecording
 	TaskStateTranscribing
+	// recording while transcribing and outputting segments as they are spoken
+	TaskStateStreaming
 )
 
-const maxHistoryLength = 100
+func (s TaskState) String() string {
+	switch s {
+	case TaskStateIdle:
+		return "idle"
+	case TaskStateRecording:
+		return "recording"
+	case TaskStateTranscribing:
+		return "transcribing"
+	case TaskStateStreaming:
+		return "streaming"
+	default:
+		return "unknown"
+	}
+}
+
+func (s TaskState) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// TaskStatus is a snapshot of what the task manager is doing
+type TaskStatus struct {
+	State TaskState
+	// why the most recent recording was stopped
+	StopReason StopReason `json:",omitempty"`
+	// problem with the most recent recording, like silent or clipped audio
+	Warning string `json:",omitempty"`
+	// history entry of the most recent recording when processing it failed,
+	// it can be retried with /api/history/<uuid>/retry
+	FailedUUID string `json:",omitempty"`
+	// level of the input, only while recording
+	Level *AudioLevel `json:",omitempty"`
+}
 
 // TaskManager is a thread safe manager for global task state
 type TaskManager struct {
 	currentTask      atomic.Pointer[TranscribeTask]
 	transcriptionRes chan *TranscriptionResult
+	streamUpdates    chan StreamUpdate
 	stateCh          chan TaskState
-	context          atomic.Pointer[string]
-	history          atomic.Pointer[[]*TranscriptionResult]
+	context          atomic.Pointer[[]*StoredContext]
+	history          atomic.Pointer[HistoryStore]
+	status           atomic.Pointer[TaskStatus]
+	level            atomic.Pointer[AudioLevel]
+
+	// always on input stream for pre-roll, nil when not in use
+	captureMu sync.Mutex
+	capture   *AudioCapture
 }
 
 // task managers ensures only only one task is running at a time and cancels
@@ -26,13 +73,14 @@ type TaskManager struct {
 var taskManager = TaskManager{
 	currentTask:      atomic.Pointer[TranscribeTask]{}, // Initialize as nil
 	transcriptionRes: make(chan *TranscriptionResult),
+	streamUpdates:    make(chan StreamUpdate, 128),
 	stateCh:          make(chan TaskState, 128),
-	context:          atomic.Pointer[string]{},
-	history:          atomic.Pointer[[]*TranscriptionResult]{},
+	context:          atomic.Pointer[[]*StoredContext]{},
+	history:          atomic.Pointer[HistoryStore]{},
 }
 
-func (tm *TaskManager) StartNewTask() *TranscribeTask {
-	newTask := NewTranscribeTask()
+func (tm *TaskManager) StartNewTask(options TaskOptions) *TranscribeTask {
+	newTask := NewTranscribeTask(options)
 
 	oldTask := tm.currentTask.Swap(newTask)
 
@@ -46,13 +94,26 @@ func (tm *TaskManager) StartNewTask() *TranscribeTask {
 	go func() {
 		// this waits for task to fish, state is closed when task is done
 		for state := range stateCh {
+			tm.status.Store(&TaskStatus{
+				State:      state,
+				StopReason: newTask.GetStopReason(),
+				Warning:    newTask.GetWarning(),
+				FailedUUID: newTask.GetFailedUUID(),
+			})
 			tm.stateCh <- state
 		}
 
+		tm.status.Store(&TaskStatus{
+			State:      TaskStateIdle,
+			StopReason: newTask.GetStopReason(),
+			Warning:    newTask.GetWarning(),
+			FailedUUID: newTask.GetFailedUUID(),
+		})
 		tm.stateCh <- TaskStateIdle
 
 		if tm.currentTask.CompareAndSwap(newTask, nil) {
-			if result := newTask.GetResult(); result != nil {
+			// streamed results have already been output
+			if result := newTask.GetResult(); result != nil && !result.Streamed {
 				tm.transcriptionRes <- result
 			}
 		}
@@ -61,14 +122,23 @@ func (tm *TaskManager) StartNewTask() *TranscribeTask {
 	return newTask
 }
 
-func (tm *TaskManager) StartOrStopTask() {
+func (tm *TaskManager) StartOrStopTask(options TaskOptions) {
 	if currentTask := tm.currentTask.Load(); currentTask != nil {
 		tm.StopRecording()
 	} else {
-		tm.StartNewTask()
+		tm.StartNewTask(options)
 	}
 }
 
+// start a task unless one is already recording or transcribing, returns nil
+// if no task was started
+func (tm *TaskManager) StartTaskIfIdle(options TaskOptions) *TranscribeTask {
+	if tm.currentTask.Load() != nil {
+		return nil
+	}
+	return tm.StartNewTask(options)
+}
+
 func (tm *TaskManager) StopRecording() {
 	if currentTask := tm.currentTask.Load(); currentTask != nil {
 		currentTask.StopRecording()
@@ -81,39 +151,165 @@ func (tm *TaskManager) Abort() {
 	}
 }
 
-func (tm *TaskManager) GetContext() string {
-	return *tm.context.Load()
+func (tm *TaskManager) GetStatus() TaskStatus {
+	var status TaskStatus
+	if stored := tm.status.Load(); stored != nil {
+		status = *stored
+	}
+	status.Level = tm.GetLevel()
+	return status
 }
 
-func (tm *TaskManager) SetContext(ctx string) {
-	tm.context.Store(&ctx)
+// publish the current input level, called from the audio callback
+func (tm *TaskManager) SetLevel(level AudioLevel) {
+	tm.level.Store(&level)
 }
 
-func (tm *TaskManager) AppendToHistory(entry *TranscriptionResult) {
-	for {
-		oldHistory := tm.history.Load()
-		if oldHistory == nil {
-			newHistory := []*TranscriptionResult{entry}
-			if tm.history.CompareAndSwap(nil, &newHistory) {
-				break
-			}
-		} else {
-			newHistory := append(*oldHistory, entry)
-			if len(newHistory) > maxHistoryLength {
-				newHistory = newHistory[len(newHistory)-maxHistoryLength:]
+func (tm *TaskManager) ClearLevel() {
+	tm.level.Store(nil)
+}
+
+// the most recent input level, nil when not recording
+func (tm *TaskManager) GetLevel() *AudioLevel {
+	return tm.level.Load()
+}
+
+// queue an edit to the output of a streaming task
+func (tm *TaskManager) SendStreamUpdate(update StreamUpdate) {
+	tm.streamUpdates <- update
+}
+
+// start or stop the always on audio capture to match the PreRoll config
+func (tm *TaskManager) UpdateCapture() error {
+	tm.captureMu.Lock()
+	defer tm.captureMu.Unlock()
+
+	if !config.PreRoll || config.PreRollMs <= 0 {
+		return tm.stopCapture()
+	}
+
+	if tm.capture != nil {
+		return nil
+	}
+
+	capture := NewAudioCapture(config.CaptureSampleRate, time.Duration(config.PreRollMs)*time.Millisecond)
+	if err := capture.Start(); err != nil {
+		return fmt.Errorf("Error starting audio capture: %v", err)
+	}
+
+	tm.capture = capture
+	return nil
+}
+
+// release the input device if the always on capture is running
+func (tm *TaskManager) StopCapture() error {
+	tm.captureMu.Lock()
+	defer tm.captureMu.Unlock()
+	return tm.stopCapture()
+}
+
+func (tm *TaskManager) stopCapture() error {
+	if tm.capture == nil {
+		return nil
+	}
+
+	capture := tm.capture
+	tm.capture = nil
+	return capture.Stop()
+}
+
+// the running always on capture, or nil
+func (tm *TaskManager) GetCapture() *AudioCapture {
+	tm.captureMu.Lock()
+	defer tm.captureMu.Unlock()
+	return tm.capture
+}
+
+// get the stored context that hasn't expired, oldest first
+func (tm *TaskManager) GetContext() []*StoredContext {
+	now := time.Now()
+	entries := []*StoredContext{}
+
+	if stored := tm.context.Load(); stored != nil {
+		for _, entry := range *stored {
+			if !entry.Expired(now) {
+				entries = append(entries, entry)
 			}
-			if tm.history.CompareAndSwap(oldHistory, &newHistory) {
-				break
+		}
+	}
+	return entries
+}
+
+// store context, replacing any from the same source. Context with empty text
+// removes the source's context
+func (tm *TaskManager) SetContext(entry *StoredContext) {
+	for {
+		oldContext := tm.context.Load()
+		newContext := []*StoredContext{}
+
+		if oldContext != nil {
+			for _, existing := range *oldContext {
+				if existing.Source != entry.Source && !existing.Expired(entry.UpdatedAt) {
+					newContext = append(newContext, existing)
+				}
 			}
 		}
+
+		if entry.Text != "" {
+			newContext = append(newContext, entry)
+		}
+
+		if tm.context.CompareAndSwap(oldContext, &newContext) {
+			break
+		}
 	}
 }
 
-// get a copy of the current history
+// load the history from disk, it's kept in memory only until this is called
+// or if it fails
+func (tm *TaskManager) OpenHistory() error {
+	dir, err := getHistoryDir()
+	if err != nil {
+		return err
+	}
+
+	store, err := openHistoryStore(dir, false)
+	if err != nil {
+		return err
+	}
+
+	tm.history.Store(store)
+	return nil
+}
+
+func (tm *TaskManager) History() *HistoryStore {
+	if store := tm.history.Load(); store != nil {
+		return store
+	}
+
+	tm.history.CompareAndSwap(nil, newHistoryStore())
+	return tm.history.Load()
+}
+
+func (tm *TaskManager) AppendToHistory(entry *TranscriptionResult) {
+	if err := tm.History().Add(entry); err != nil {
+		log.Printf("Error saving history: %v\n", err)
+	}
+}
+
+// get a copy of the current history, oldest first
 func (tm *TaskManager) GetHistory() []*TranscriptionResult {
-	history := tm.history.Load()
-	if history == nil {
-		return []*TranscriptionResult{}
+	return tm.History().List()
+}
+
+// the most recent transcription that was output, skipping failed ones. nil if
+// there's none
+func (tm *TaskManager) LastOutput() *TranscriptionResult {
+	history := tm.GetHistory()
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Error == "" || history[i].Streamed {
+			return history[i]
+		}
 	}
-	return append([]*TranscriptionResult(nil), *history...)
+	return nil
 }
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// TranscriptionOptions are the per request parameters passed to a Transcriber
+type TranscriptionOptions struct {
+	Language    string // empty to let the backend detect the language
+	Temperature float32
+	Prompt      string
+	Segments    bool // ask for segment timestamps, for subtitles and chunk merging
+}
+
+// Transcriber turns an audio file into text
+type Transcriber interface {
+	// name of the backend, stored in the transcription result
+	Name() string
+	Transcribe(ctx context.Context, audioPath string, options TranscriptionOptions) (*TranscriptionResult, error)
+}
+
+// get the transcriber for the backend selected in the config
+func getTranscriber() (Transcriber, error) {
+	return getTranscriberByName(config.TranscriptionBackend)
+}
+
+func getTranscriberByName(name string) (Transcriber, error) {
+	switch name {
+	case "", "openai":
+		return NewOpenAITranscriber()
+	case "http":
+		return NewHTTPTranscriber()
+	default:
+		return nil, fmt.Errorf("Unknown transcription backend: %s", name)
+	}
+}
+
+// in my testing the Prompt parameter is not very good at repairing the transcription, so we do a two pass process instead
+func transcribeAudio(ctx context.Context, audioFilePath string, instructions string) (*TranscriptionResult, error) {
+	transcriber, err := getTranscriber()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := transcribeWithRetry(ctx, transcriber, audioFilePath, defaultTranscriptionOptions())
+	if err != nil {
+		return nil, &StageError{Stage: StageTranscribe, Err: err}
+	}
+
+	return repairTranscription(ctx, result, instructions)
+}
+
+// transcribe with a backend, trying again on transient errors
+func transcribeWithRetry(ctx context.Context, transcriber Transcriber, audioPath string, options TranscriptionOptions) (*TranscriptionResult, error) {
+	var result *TranscriptionResult
+	err := withRetry(ctx, transcriber.Name()+" transcription", func() error {
+		var err error
+		result, err = transcriber.Transcribe(ctx, audioPath, options)
+		return err
+	})
+	return result, err
+}
+
+func defaultTranscriptionOptions() TranscriptionOptions {
+	return TranscriptionOptions{
+		Language:    config.TranscriptionLanguage(),
+		Temperature: config.Temperature,
+		Segments:    true,
+	}
+}
+
+// run the second pass over a transcription if there are any instructions. On
+// failure the unrepaired result is kept in the StageError
+func repairTranscription(ctx context.Context, result *TranscriptionResult, instructions string) (*TranscriptionResult, error) {
+	if instructions == "" {
+		return result, nil
+	}
+
+	result.RepairPrompt = instructions
+	fixedText, err := fixTranscription(ctx, result.Original, instructions)
+	if err != nil {
+		return nil, &StageError{
+			Stage:  StageRepair,
+			Err:    fmt.Errorf("Error fixing transcription: %w", err),
+			Result: result,
+		}
+	}
+	result.Modified = fixedText
+
+	return result, nil
+}
+
+// HTTPTranscriber sends audio to a self-hosted whisper server. The request is
+// a multipart form upload that works with the whisper.cpp server (/inference)
+// and OpenAI compatible servers like faster-whisper-server
+// (/v1/audio/transcriptions)
+type HTTPTranscriber struct {
+	URL    string
+	Model  string
+	APIKey string
+	client *http.Client
+}
+
+func NewHTTPTranscriber() (*HTTPTranscriber, error) {
+	if config.WhisperURL == "" {
+		return nil, fmt.Errorf("WhisperURL is not set")
+	}
+
+	return &HTTPTranscriber{
+		URL:    config.WhisperURL,
+		Model:  config.WhisperModel,
+		APIKey: config.WhisperKey,
+		client: &http.Client{},
+	}, nil
+}
+
+func (t *HTTPTranscriber) Name() string {
+	return "http"
+}
+
+// the subset of the whisper json response formats that we use
+type whisperResponse struct {
+	Text     string `json:"text"`
+	Segments []struct {
+		Start float64 `json:"start"`
+		End   float64 `json:"end"`
+		Text  string  `json:"text"`
+	} `json:"segments"`
+}
+
+func (t *HTTPTranscriber) Transcribe(ctx context.Context, audioPath string, options TranscriptionOptions) (*TranscriptionResult, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+
+	audioFile, err := os.Open(audioPath)
+	if err != nil {
+		return nil, fmt.Errorf("Error opening audio file: %v", err)
+	}
+	defer audioFile.Close()
+
+	fileField, err := form.CreateFormFile("file", filepath.Base(audioPath))
+	if err != nil {
+		return nil, fmt.Errorf("Error creating form file: %v", err)
+	}
+
+	if _, err := io.Copy(fileField, audioFile); err != nil {
+		return nil, fmt.Errorf("Error reading audio file: %v", err)
+	}
+
+	// verbose_json is the only format with segment timestamps
+	responseFormat := "json"
+	if options.Segments {
+		responseFormat = "verbose_json"
+	}
+
+	fields := map[string]string{
+		"response_format": responseFormat,
+		"temperature":     strconv.FormatFloat(float64(options.Temperature), 'f', -1, 32),
+	}
+
+	if t.Model != "" {
+		fields["model"] = t.Model
+	}
+
+	if options.Language != "" {
+		fields["language"] = options.Language
+	}
+
+	if options.Prompt != "" {
+		fields["prompt"] = options.Prompt
+	}
+
+	for name, value := range fields {
+		if err := form.WriteField(name, value); err != nil {
+			return nil, fmt.Errorf("Error writing form field %s: %v", name, err)
+		}
+	}
+
+	if err := form.Close(); err != nil {
+		return nil, fmt.Errorf("Error finishing form: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.URL, &body)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating request: %v", err)
+	}
+
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	if t.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+t.APIKey)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Error sending transcription request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading transcription response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Error from transcription server: %w", newHTTPStatusError(resp, bytes.TrimSpace(respBody)))
+	}
+
+	var whisperResp whisperResponse
+	if trimmed := bytes.TrimSpace(respBody); len(trimmed) > 0 && trimmed[0] != '{' {
+		// servers that ignore response_format may answer with plain text
+		whisperResp.Text = string(trimmed)
+	} else if err := json.Unmarshal(respBody, &whisperResp); err != nil {
+		return nil, fmt.Errorf("Error decoding transcription response: %v", err)
+	}
+
+	result := NewTranscriptionResult()
+	result.Backend = t.Name()
+	result.Original = strings.TrimSpace(whisperResp.Text)
+
+	for _, segment := range whisperResp.Segments {
+		result.Segments = append(result.Segments, TranscriptionSegment{
+			Start: segment.Start,
+			End:   segment.End,
+			Text:  segment.Text,
+		})
+	}
+
+	return result, nil
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+func writeTestAudio(t *testing.T) string {
+	t.Helper()
+	audioPath := filepath.Join(t.TempDir(), "recording.mp3")
+	if err := os.WriteFile(audioPath, []byte("AUDIO"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return audioPath
+}
+
+func TestHTTPTranscriberRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q", got)
+		}
+
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Fatalf("parsing form: %v", err)
+		}
+
+		expected := map[string]string{
+			"model":           "large-v3",
+			"language":        "en",
+			"prompt":          "talkxtyper",
+			"response_format": "verbose_json",
+			"temperature":     "0.5",
+		}
+		for name, value := range expected {
+			if got := r.FormValue(name); got != value {
+				t.Errorf("field %s = %q, want %q", name, got, value)
+			}
+		}
+
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			t.Fatalf("missing file: %v", err)
+		}
+		audio, _ := io.ReadAll(file)
+		if header.Filename != "recording.mp3" || string(audio) != "AUDIO" {
+			t.Errorf("file = %s %q", header.Filename, audio)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{
+			"text": " hello there ",
+			"segments": [
+				{"id": 0, "start": 0.0, "end": 1.25, "text": " hello"},
+				{"id": 1, "start": 1.25, "end": 2.5, "text": " there"}
+			]
+		}`)
+	}))
+	defer server.Close()
+
+	transcriber := &HTTPTranscriber{URL: server.URL, Model: "large-v3", APIKey: "secret", client: server.Client()}
+	result, err := transcriber.Transcribe(context.Background(), writeTestAudio(t), TranscriptionOptions{
+		Language:    "en",
+		Temperature: 0.5,
+		Prompt:      "talkxtyper",
+		Segments:    true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Original != "hello there" || result.Backend != "http" {
+		t.Errorf("result = %q from %s", result.Original, result.Backend)
+	}
+
+	expected := []TranscriptionSegment{{0, 1.25, " hello"}, {1.25, 2.5, " there"}}
+	if len(result.Segments) != len(expected) {
+		t.Fatalf("got %d segments, want %d", len(result.Segments), len(expected))
+	}
+	for i, segment := range expected {
+		if result.Segments[i] != segment {
+			t.Errorf("segment %d = %+v, want %+v", i, result.Segments[i], segment)
+		}
+	}
+}
+
+func TestHTTPTranscriberOptionalFields(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "" {
+			t.Errorf("Authorization = %q, want none", got)
+		}
+		r.ParseMultipartForm(1 << 20)
+		for _, name := range []string{"model", "language", "prompt"} {
+			if _, ok := r.MultipartForm.Value[name]; ok {
+				t.Errorf("unexpected field %s", name)
+			}
+		}
+		// servers that ignore response_format answer with plain text
+		io.WriteString(w, "just text\n")
+	}))
+	defer server.Close()
+
+	transcriber := &HTTPTranscriber{URL: server.URL, client: server.Client()}
+	result, err := transcriber.Transcribe(context.Background(), writeTestAudio(t), TranscriptionOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Original != "just text" || len(result.Segments) != 0 {
+		t.Errorf("result = %q with %d segments", result.Original, len(result.Segments))
+	}
+}
+
+func TestHTTPTranscriberErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/invalid" {
+			io.WriteString(w, `{"text": `)
+			return
+		}
+		http.Error(w, "model not loaded", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	transcriber := &HTTPTranscriber{URL: server.URL, client: server.Client()}
+	_, err := transcriber.Transcribe(context.Background(), writeTestAudio(t), TranscriptionOptions{})
+
+	var statusErr *HTTPStatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("error = %v, want HTTPStatusError", err)
+	}
+	if statusErr.StatusCode != http.StatusServiceUnavailable || statusErr.Body != "model not loaded" {
+		t.Errorf("status error = %+v", statusErr)
+	}
+
+	transcriber.URL = server.URL + "/invalid"
+	if _, err := transcriber.Transcribe(context.Background(), writeTestAudio(t), TranscriptionOptions{}); err == nil {
+		t.Error("expected an error decoding invalid JSON")
+	}
+}
+
+func TestOpenAITranscriberResponseFormat(t *testing.T) {
+	var formats []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseMultipartForm(1 << 20)
+		format := r.FormValue("response_format")
+		formats = append(formats, format)
+
+		w.Header().Set("Content-Type", "application/json")
+		if format == "verbose_json" && r.FormValue("model") == "strict" {
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, `{"error": {"message": "response_format 'verbose_json' is not supported", "type": "invalid_request_error"}}`)
+			return
+		}
+		io.WriteString(w, `{"text": "hello"}`)
+	}))
+	defer server.Close()
+
+	clientConfig := openai.DefaultConfig("secret")
+	clientConfig.BaseURL = server.URL
+	client := openai.NewClientWithConfig(clientConfig)
+
+	tests := []struct {
+		model    string
+		segments bool
+		formats  []string
+	}{
+		{"whisper-1", true, []string{"verbose_json"}},
+		{"whisper-1", false, []string{"json"}},
+		{"gpt-4o-transcribe", true, []string{"json"}},
+		{"gpt-4o-mini-transcribe", true, []string{"json"}},
+		// a compatible server that rejects verbose_json
+		{"strict", true, []string{"verbose_json", "json"}},
+	}
+
+	for _, test := range tests {
+		formats = nil
+		transcriber := &OpenAITranscriber{client: client, model: test.model}
+		result, err := transcriber.Transcribe(context.Background(), writeTestAudio(t), TranscriptionOptions{Segments: test.segments})
+		if err != nil {
+			t.Errorf("%s: %v", test.model, err)
+			continue
+		}
+		if result.Original != "hello" {
+			t.Errorf("%s: text = %q", test.model, result.Original)
+		}
+		if strings.Join(formats, ",") != strings.Join(test.formats, ",") {
+			t.Errorf("%s with segments %v: requested %v, want %v", test.model, test.segments, formats, test.formats)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"math"
+	"time"
+)
+
+const vadFrameMs = 30
+
+// number of consecutive speech frames needed before we consider the user to
+// have started talking, filters out clicks and bumps
+const vadMinSpeechFrames = 3
+
+// VAD is a simple voice activity detector over 16 bit mono samples. The audio
+// is split into short frames, and a frame is considered speech when its energy
+// is well above the tracked noise floor and its zero crossing rate isn't noise
+// like. Not thread safe.
+type VAD struct {
+	frameSize   int
+	sensitivity float64
+	pending     []int16
+	noiseFloor  float64
+
+	speechRun   int // consecutive speech frames
+	silenceRun  int // consecutive silent frames
+	heardSpeech bool
+	framesTotal int
+}
+
+// sensitivity is from 0 to 1, higher values treat quieter audio as speech
+func NewVAD(sampleRate int, sensitivity float64) *VAD {
+	return &VAD{
+		frameSize:   sampleRate * vadFrameMs / 1000,
+		sensitivity: math.Max(0, math.Min(1, sensitivity)),
+	}
+}
+
+// calculate the RMS energy (0 to 1) and zero crossing rate (crossings per
+// sample) of a frame
+func frameStats(frame []int16) (float64, float64) {
+	if len(frame) == 0 {
+		return 0, 0
+	}
+
+	var sum float64
+	crossings := 0
+	for i, sample := range frame {
+		s := float64(sample) / 32768
+		sum += s * s
+		if i > 0 && (sample >= 0) != (frame[i-1] >= 0) {
+			crossings++
+		}
+	}
+
+	return math.Sqrt(sum / float64(len(frame))), float64(crossings) / float64(len(frame))
+}
+
+// classify a single frame as speech, updating the noise floor estimate
+func (v *VAD) isSpeech(frame []int16) bool {
+	rms, zcr := frameStats(frame)
+
+	if v.framesTotal == 0 || rms < v.noiseFloor {
+		v.noiseFloor = rms
+	}
+	v.framesTotal++
+
+	// energy must be this many times above the noise floor, from 2x at max
+	// sensitivity to 10x at the lowest
+	ratio := 2 + 8*(1-v.sensitivity)
+	minEnergy := 0.001 + 0.01*(1-v.sensitivity)
+	threshold := math.Max(v.noiseFloor*ratio, minEnergy)
+
+	// white noise crosses zero about every other sample, voiced speech much
+	// less. High crossing frames need more energy to count as speech
+	speech := rms > threshold && (zcr < 0.35 || rms > threshold*2)
+
+	if !speech {
+		// slowly follow rising background noise
+		v.noiseFloor = v.noiseFloor*0.95 + rms*0.05
+	}
+
+	return speech
+}
+
+// Process feeds more samples into the detector
+func (v *VAD) Process(samples []int16) {
+	v.pending = append(v.pending, samples...)
+
+	offset := 0
+	for len(v.pending)-offset >= v.frameSize {
+		if v.isSpeech(v.pending[offset : offset+v.frameSize]) {
+			v.speechRun++
+			if v.speechRun >= vadMinSpeechFrames {
+				v.heardSpeech = true
+				v.silenceRun = 0
+			}
+		} else {
+			v.speechRun = 0
+			v.silenceRun++
+		}
+
+		offset += v.frameSize
+	}
+
+	// move the leftover samples to the front so the backing array is reused
+	// instead of growing forever
+	v.pending = v.pending[:copy(v.pending, v.pending[offset:])]
+}
+
+// HeardSpeech returns true once any speech has been detected since the last
+// Reset
+func (v *VAD) HeardSpeech() bool {
+	return v.heardSpeech
+}
+
+// TrailingSilence is how long it has been silent after the last speech
+func (v *VAD) TrailingSilence() time.Duration {
+	return time.Duration(v.silenceRun*vadFrameMs) * time.Millisecond
+}
+
+// Reset forgets any detected speech so the next utterance can be detected,
+// the noise floor estimate is kept
+func (v *VAD) Reset() {
+	v.heardSpeech = false
+	v.speechRun = 0
+	v.silenceRun = 0
+}
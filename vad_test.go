@@ -0,0 +1,92 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+	"time"
+)
+
+// a tone at amplitude, as a fraction of full scale, plus uniform noise up to
+// noise, clipped at full scale. The noise is seeded so tests are repeatable
+func testSignal(sampleRate int, duration time.Duration, amplitude, freq, noise float64) []int16 {
+	random := rand.New(rand.NewSource(1))
+	samples := make([]int16, int(duration.Seconds()*float64(sampleRate)))
+	for i := range samples {
+		v := amplitude*math.Sin(2*math.Pi*freq*float64(i)/float64(sampleRate)) + noise*(random.Float64()*2-1)
+		samples[i] = int16(max(-32768, min(32767, math.Round(v*32767))))
+	}
+	return samples
+}
+
+func TestVAD(t *testing.T) {
+	const sampleRate = 16000
+
+	quiet := func(duration time.Duration) []int16 {
+		return testSignal(sampleRate, duration, 0, 0, 0.003)
+	}
+	speech := func(duration time.Duration) []int16 {
+		return testSignal(sampleRate, duration, 0.2, 220, 0.003)
+	}
+
+	tests := []struct {
+		name            string
+		samples         []int16
+		heardSpeech     bool
+		trailingSilence time.Duration
+	}{
+		{"digital silence", make([]int16, sampleRate), false, 0},
+		{"background noise", quiet(2 * time.Second), false, 0},
+		{"speech", slices.Concat(quiet(500*time.Millisecond), speech(time.Second)), true, 0},
+		{"speech then silence", slices.Concat(quiet(500*time.Millisecond), speech(time.Second), quiet(time.Second)), true, time.Second},
+		// shorter than vadMinSpeechFrames
+		{"click", slices.Concat(quiet(500*time.Millisecond), speech(40*time.Millisecond), quiet(500*time.Millisecond)), false, 0},
+		{"clipped speech", slices.Concat(quiet(500*time.Millisecond), testSignal(sampleRate, time.Second, 4, 220, 0)), true, 0},
+	}
+
+	for _, test := range tests {
+		vad := NewVAD(sampleRate, 0.5)
+
+		// in buffers like the audio callback, which don't line up with frames
+		for offset := 0; offset < len(test.samples); offset += 256 {
+			vad.Process(test.samples[offset:min(offset+256, len(test.samples))])
+		}
+
+		if vad.HeardSpeech() != test.heardSpeech {
+			t.Errorf("%s: heard speech %v, want %v", test.name, vad.HeardSpeech(), test.heardSpeech)
+		}
+
+		// the last partial frame isn't processed yet, and frames straddle the
+		// change from speech
+		silence := vad.TrailingSilence()
+		if test.heardSpeech && (silence < test.trailingSilence-2*vadFrameMs*time.Millisecond || silence > test.trailingSilence) {
+			t.Errorf("%s: trailing silence %v, want %v", test.name, silence, test.trailingSilence)
+		}
+
+		if cap(vad.pending) > 2*(vad.frameSize+256) {
+			t.Errorf("%s: pending buffer grew to %d samples", test.name, cap(vad.pending))
+		}
+	}
+}
+
+func TestVADReset(t *testing.T) {
+	const sampleRate = 16000
+
+	vad := NewVAD(sampleRate, 0.5)
+	vad.Process(testSignal(sampleRate, 500*time.Millisecond, 0, 0, 0.003))
+	vad.Process(testSignal(sampleRate, time.Second, 0.2, 220, 0.003))
+	if !vad.HeardSpeech() {
+		t.Fatal("didn't hear speech")
+	}
+
+	vad.Reset()
+	if vad.HeardSpeech() || vad.TrailingSilence() != 0 {
+		t.Errorf("after reset: heard speech %v, trailing silence %v", vad.HeardSpeech(), vad.TrailingSilence())
+	}
+
+	vad.Process(testSignal(sampleRate, time.Second, 0, 0, 0.003))
+	if vad.HeardSpeech() {
+		t.Error("heard speech in silence after reset")
+	}
+}